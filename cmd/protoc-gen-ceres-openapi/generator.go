@@ -0,0 +1,526 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/proto/api"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemaPrefix   = "#/components/schemas/"
+	responsePrefix = "#/components/responses/"
+	statusSchema   = "errors.Status"
+	errorResponse  = "Error"
+)
+
+// routeExtensions 路由注解，顺序与 protoc-gen-ceres 保持一致
+var routeExtensions = []struct {
+	ext    protoreflect.ExtensionType
+	method string
+}{
+	{api.E_Get, http.MethodGet},
+	{api.E_Post, http.MethodPost},
+	{api.E_Put, http.MethodPut},
+	{api.E_Delete, http.MethodDelete},
+	{api.E_Options, http.MethodOptions},
+	{api.E_Patch, http.MethodPatch},
+	{api.E_Head, http.MethodHead},
+	{api.E_Connect, http.MethodConnect},
+	{api.E_Trace, http.MethodTrace},
+}
+
+// options 插件参数
+type options struct {
+	title       string
+	description string
+	version     string
+	server      string
+	merge       bool
+	filename    string
+	omitempty   bool
+}
+
+// Generator 文档生成器，字段结构与框架默认的json编解码保持一致，即使用proto字段名与encoding/json的编码方式
+type Generator struct {
+	gen     *protogen.Plugin
+	opts    options
+	doc     *Document
+	tags    map[string]bool
+	schemas map[protoreflect.FullName]bool
+	errors  map[int]map[string]bool
+}
+
+// NewGenerator 创建文档生成器
+func NewGenerator(gen *protogen.Plugin, opts options) *Generator {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: &Info{
+			Title:       opts.title,
+			Description: opts.description,
+			Version:     opts.version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: &Components{
+			Schemas:   map[string]*Schema{statusSchema: errorSchema(0, nil)},
+			Responses: map[string]*Response{errorResponse: jsonResponse("Error", statusSchema)},
+		},
+	}
+	if opts.server != "" {
+		doc.Servers = []*Server{{URL: opts.server}}
+	}
+	return &Generator{
+		gen:     gen,
+		opts:    opts,
+		doc:     doc,
+		tags:    make(map[string]bool),
+		schemas: make(map[protoreflect.FullName]bool),
+		errors:  make(map[int]map[string]bool),
+	}
+}
+
+// AddFile 添加文件中的所有服务
+func (g *Generator) AddFile(file *protogen.File) {
+	codes := g.addErrors(file)
+	for _, service := range file.Services {
+		if g.doc.Info.Title == "" {
+			g.doc.Info.Title = service.GoName
+		}
+		g.addService(service, codes)
+	}
+}
+
+// Write 写出文档
+func (g *Generator) Write(filename string) error {
+	data, err := json.MarshalIndent(g.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = g.gen.NewGeneratedFile(filename, "").Write(append(data, '\n'))
+	return err
+}
+
+// addService 添加服务下的所有接口，客户端流无法映射为http，服务端流映射为SSE
+func (g *Generator) addService(service *protogen.Service, codes []int) {
+	tag := service.GoName
+	if !g.tags[tag] {
+		g.tags[tag] = true
+		g.doc.Tags = append(g.doc.Tags, &Tag{Name: tag, Description: comment(service.Comments)})
+	}
+	deprecated := service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated()
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() {
+			continue
+		}
+		httpMethod, path := route(method)
+		if path == "" {
+			if g.opts.omitempty {
+				continue
+			}
+			httpMethod, path = http.MethodPost, fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())
+		}
+		path, params := convertPath(path)
+		op := g.buildOperation(service, method, httpMethod, params, codes)
+		op.Deprecated = op.Deprecated || deprecated
+		item, ok := g.doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			g.doc.Paths[path] = item
+		}
+		if !item.set(httpMethod, op) {
+			g.gen.Error(fmt.Errorf("method %s of %s cannot be described by openapi", httpMethod, method.Desc.FullName()))
+		}
+	}
+}
+
+// buildOperation 构建接口，字段位置由 api.path/query/header/form/json 注解决定，未注解的字段在无请求体的方法中作为query参数
+func (g *Generator) buildOperation(service *protogen.Service, method *protogen.Method, httpMethod string, pathParams []string, codes []int) *Operation {
+	summary, description := splitComment(comment(method.Comments))
+	op := &Operation{
+		Tags:        []string{service.GoName},
+		Summary:     summary,
+		Description: description,
+		OperationID: service.GoName + "_" + method.GoName,
+		Responses:   make(map[string]*Response),
+		Deprecated:  method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated(),
+	}
+	hasBody := httpMethod == http.MethodPost || httpMethod == http.MethodPut || httpMethod == http.MethodPatch
+	bound := make(map[string]bool)
+	var formFields, bodyFields []*protogen.Field
+	renamed := false
+	for _, field := range method.Input.Fields {
+		name, in := fieldLocation(field)
+		if in == "" {
+			switch {
+			case contains(pathParams, name):
+				in = "path"
+			case !hasBody:
+				in = "query"
+			default:
+				in = "body"
+			}
+		} else if in == "body" && name != string(field.Desc.Name()) {
+			renamed = true
+		}
+		if in == "body" && !hasBody {
+			in = "query"
+		}
+		switch in {
+		case "body":
+			bodyFields = append(bodyFields, field)
+		case "form":
+			if hasBody {
+				formFields = append(formFields, field)
+				continue
+			}
+			in = "query"
+			fallthrough
+		default:
+			if in == "query" && (field.Desc.IsMap() || (field.Desc.Kind() == protoreflect.MessageKind && !field.Desc.IsList())) {
+				continue
+			}
+			bound[name] = in == "path"
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:        name,
+				In:          in,
+				Description: fieldComment(field),
+				Required:    in == "path",
+				Schema:      g.fieldSchema(field),
+			})
+		}
+	}
+	for _, name := range pathParams {
+		if !bound[name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	if len(formFields) > 0 {
+		schema := g.objectSchema(formFields)
+		op.RequestBody = &RequestBody{Content: map[string]*MediaType{
+			"application/x-www-form-urlencoded": {Schema: schema},
+			"multipart/form-data":               {Schema: schema},
+		}}
+	} else if len(bodyFields) > 0 {
+		schema := g.objectSchema(bodyFields)
+		if len(bodyFields) == len(method.Input.Fields) && !renamed {
+			schema = g.messageSchema(method.Input)
+		}
+		op.RequestBody = &RequestBody{Content: map[string]*MediaType{"application/json": {Schema: schema}}}
+	}
+	if method.Desc.IsStreamingServer() {
+		op.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+			Description: "Server-sent events, the data of each event is a json encoded " + string(method.Output.Desc.FullName()),
+			Content:     map[string]*MediaType{"text/event-stream": {Schema: &Schema{Type: "string"}}},
+		}
+	} else {
+		op.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+			Description: "OK",
+			Content:     map[string]*MediaType{"application/json": {Schema: g.messageSchema(method.Output)}},
+		}
+	}
+	for _, code := range codes {
+		op.Responses[strconv.Itoa(code)] = &Response{Ref: responsePrefix + errorResponse + strconv.Itoa(code)}
+	}
+	op.Responses["default"] = &Response{Ref: responsePrefix + errorResponse}
+	return op
+}
+
+// addErrors 收集文件及其依赖中由 protoc-gen-ceres-error 定义的错误，返回涉及的状态码
+func (g *Generator) addErrors(file *protogen.File) []int {
+	files := []*protogen.File{file}
+	imports := file.Desc.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if f, ok := g.gen.FilesByPath[imports.Get(i).Path()]; ok {
+			files = append(files, f)
+		}
+	}
+	reasons := make(map[int]map[string]bool)
+	for _, f := range files {
+		for _, enum := range f.Enums {
+			defaultCode, _ := proto.GetExtension(enum.Desc.Options(), errors.E_DefaultCode).(int32)
+			for _, v := range enum.Values {
+				code := defaultCode
+				if c, _ := proto.GetExtension(v.Desc.Options(), errors.E_Code).(int32); c != 0 {
+					code = c
+				}
+				if code <= 0 || code > 600 {
+					continue
+				}
+				if reasons[int(code)] == nil {
+					reasons[int(code)] = make(map[string]bool)
+				}
+				reasons[int(code)][string(v.Desc.Name())] = true
+			}
+		}
+	}
+	codes := make([]int, 0, len(reasons))
+	for code, set := range reasons {
+		codes = append(codes, code)
+		if g.errors[code] == nil {
+			g.errors[code] = make(map[string]bool)
+		}
+		for reason := range set {
+			g.errors[code][reason] = true
+		}
+		all := make([]string, 0, len(g.errors[code]))
+		for reason := range g.errors[code] {
+			all = append(all, reason)
+		}
+		sort.Strings(all)
+		name := errorResponse + strconv.Itoa(code)
+		g.doc.Components.Schemas[name] = errorSchema(code, all)
+		g.doc.Components.Responses[name] = jsonResponse(http.StatusText(code)+": "+strings.Join(all, ", "), name)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// objectSchema 由部分字段构建对象结构
+func (g *Generator) objectSchema(fields []*protogen.Field) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(fields))}
+	for _, field := range fields {
+		name, _ := fieldLocation(field)
+		schema.Properties[name] = g.fieldSchema(field)
+	}
+	return schema
+}
+
+// messageSchema 返回消息结构的引用，首次引用时写入components
+func (g *Generator) messageSchema(message *protogen.Message) *Schema {
+	name := message.Desc.FullName()
+	ref := &Schema{Ref: schemaPrefix + string(name)}
+	if g.schemas[name] {
+		return ref
+	}
+	g.schemas[name] = true
+	schema := &Schema{
+		Type:        "object",
+		Description: comment(message.Comments),
+		Properties:  make(map[string]*Schema, len(message.Fields)),
+		Deprecated:  message.Desc.Options().(*descriptorpb.MessageOptions).GetDeprecated(),
+	}
+	g.doc.Components.Schemas[string(name)] = schema
+	for _, field := range message.Fields {
+		schema.Properties[jsonName(field)] = g.fieldSchema(field)
+	}
+	return ref
+}
+
+// fieldSchema 字段结构
+func (g *Generator) fieldSchema(field *protogen.Field) *Schema {
+	if field.Desc.IsMap() {
+		return &Schema{
+			Type:                 "object",
+			Description:          fieldComment(field),
+			AdditionalProperties: g.kindSchema(field.Message.Fields[1]),
+		}
+	}
+	schema := g.kindSchema(field)
+	if field.Desc.IsList() {
+		schema = &Schema{Type: "array", Items: schema}
+	}
+	if schema.Ref == "" {
+		if desc := fieldComment(field); desc != "" {
+			schema.Description = desc
+		}
+		schema.Deprecated = field.Desc.Options().(*descriptorpb.FieldOptions).GetDeprecated()
+	}
+	return schema
+}
+
+// kindSchema 字段类型对应的结构，64位整数与枚举按encoding/json编码为数字
+func (g *Generator) kindSchema(field *protogen.Field) *Schema {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "integer", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		schema := &Schema{Type: "integer", Format: "int32"}
+		names := make([]string, 0, len(field.Enum.Values))
+		for _, v := range field.Enum.Values {
+			schema.Enum = append(schema.Enum, int32(v.Desc.Number()))
+			names = append(names, fmt.Sprintf("%d: %s", v.Desc.Number(), v.Desc.Name()))
+		}
+		schema.Description = strings.Join(names, ", ")
+		return schema
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageSchema(field.Message)
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// set 设置方法对应的接口，openapi不支持CONNECT
+func (p *PathItem) set(method string, op *Operation) bool {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodOptions:
+		p.Options = op
+	case http.MethodHead:
+		p.Head = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodTrace:
+		p.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+// route 获取方法的路由注解
+func route(method *protogen.Method) (string, string) {
+	for _, r := range routeExtensions {
+		if path, ok := proto.GetExtension(method.Desc.Options(), r.ext).(string); ok && path != "" {
+			return r.method, path
+		}
+	}
+	return "", ""
+}
+
+// convertPath 将 /user/:id 与 /static/*path 形式的路由转为 /user/{id}，并返回路径参数
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		var name string
+		switch {
+		case len(seg) > 1 && (seg[0] == ':' || seg[0] == '*'):
+			name = seg[1:]
+		case len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}':
+			name, _, _ = strings.Cut(seg[1:len(seg)-1], "=")
+		default:
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// fieldLocation 获取字段的参数名与位置，未注解时位置为空
+func fieldLocation(field *protogen.Field) (string, string) {
+	opts := field.Desc.Options()
+	for _, loc := range []struct {
+		ext protoreflect.ExtensionType
+		in  string
+	}{
+		{api.E_Path, "path"},
+		{api.E_Header, "header"},
+		{api.E_Query, "query"},
+		{api.E_Form, "form"},
+		{api.E_Json, "body"},
+	} {
+		if name, ok := proto.GetExtension(opts, loc.ext).(string); ok && name != "" {
+			return name, loc.in
+		}
+	}
+	return string(field.Desc.Name()), ""
+}
+
+// jsonName 字段的json名称
+func jsonName(field *protogen.Field) string {
+	if name, ok := proto.GetExtension(field.Desc.Options(), api.E_Json).(string); ok && name != "" {
+		return name
+	}
+	return string(field.Desc.Name())
+}
+
+// errorSchema 错误结构，与 errors.Status 一致
+func errorSchema(code int, reasons []string) *Schema {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":     {Type: "integer", Format: "int32", Description: "错误码"},
+			"reason":   {Type: "string", Description: "错误原因"},
+			"message":  {Type: "string", Description: "错误信息"},
+			"metadata": {Type: "object", Description: "附加数据", AdditionalProperties: &Schema{Type: "string"}},
+		},
+	}
+	if code > 0 {
+		schema.Properties["code"].Enum = []interface{}{code}
+	}
+	for _, reason := range reasons {
+		schema.Properties["reason"].Enum = append(schema.Properties["reason"].Enum, reason)
+	}
+	return schema
+}
+
+// jsonResponse 引用结构的json响应
+func jsonResponse(description, schema string) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: schemaPrefix + schema}}},
+	}
+}
+
+// fieldComment 字段注释，优先使用前置注释
+func fieldComment(field *protogen.Field) string {
+	if c := comment(field.Comments); c != "" {
+		return c
+	}
+	return strings.TrimSpace(string(field.Comments.Trailing))
+}
+
+// comment 前置注释
+func comment(set protogen.CommentSet) string {
+	return strings.TrimSpace(string(set.Leading))
+}
+
+// splitComment 将注释拆分为摘要与描述
+func splitComment(c string) (string, string) {
+	summary, description, _ := strings.Cut(c, "\n")
+	return strings.TrimSpace(summary), strings.TrimSpace(description)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
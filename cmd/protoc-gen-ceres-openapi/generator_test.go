@@ -0,0 +1,161 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/proto/api"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+	"testing"
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, ext protoreflect.ExtensionType, value string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+	if ext != nil {
+		f.Options = &descriptorpb.FieldOptions{}
+		proto.SetExtension(f.Options, ext, value)
+	}
+	return f
+}
+
+func method(name, input, output string, ext protoreflect.ExtensionType, path string, stream bool) *descriptorpb.MethodDescriptorProto {
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, ext, path)
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(".user.v1." + input),
+		OutputType:      proto.String(".user.v1." + output),
+		Options:         opts,
+		ServerStreaming: proto.Bool(stream),
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	roles := field("roles", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, nil, "")
+	roles.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	roles.TypeName = proto.String(".user.v1.Role")
+	reasonOpts := &descriptorpb.EnumOptions{}
+	proto.SetExtension(reasonOpts, errors.E_DefaultCode, int32(500))
+	notFoundOpts := &descriptorpb.EnumValueOptions{}
+	proto.SetExtension(notFoundOpts, errors.E_Code, int32(404))
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("user/v1/user.proto"),
+		Package: proto.String("user.v1"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/user/v1;v1")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{Name: proto.String("Role"), Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("GUEST"), Number: proto.Int32(0)},
+				{Name: proto.String("ADMIN"), Number: proto.Int32(1)},
+			}},
+			{Name: proto.String("ErrorReason"), Options: reasonOpts, Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN_ERROR"), Number: proto.Int32(0)},
+				{Name: proto.String("USER_NOT_FOUND"), Number: proto.Int32(1), Options: notFoundOpts},
+			}},
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, nil, ""),
+				field("token", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, api.E_Header, "Authorization"),
+				field("fields", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, nil, ""),
+			}},
+			{Name: proto.String("UpdateUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, api.E_Path, "id"),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, api.E_Json, "nickName"),
+			}},
+			{Name: proto.String("User"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, nil, ""),
+				field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, api.E_Json, "nickName"),
+				roles,
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("UserService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetUser", "GetUserRequest", "User", api.E_Get, "/users/:id", false),
+				method("UpdateUser", "UpdateUserRequest", "User", api.E_Put, "/users/:id", false),
+				method("WatchUsers", "GetUserRequest", "User", api.E_Get, "/users/:id/watch", true),
+			},
+		}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGenerator(gen, options{version: "1.0.0", omitempty: true})
+	g.AddFile(gen.Files[0])
+	if err = g.Write("openapi.json"); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(*resp.Error)
+	}
+	var doc Document
+	if err = json.Unmarshal([]byte(resp.File[0].GetContent()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != "UserService" {
+		t.Fatalf("want title UserService, got %s", doc.Info.Title)
+	}
+	item := doc.Paths["/users/{id}"]
+	if item == nil || item.Get == nil || item.Put == nil {
+		t.Fatalf("want get and put on /users/{id}, got %+v", doc.Paths)
+	}
+	in := make(map[string]string)
+	for _, p := range item.Get.Parameters {
+		in[p.Name] = p.In
+	}
+	if in["id"] != "path" || in["Authorization"] != "header" || in["fields"] != "query" {
+		t.Fatalf("unexpected parameters: %v", in)
+	}
+	body := item.Put.RequestBody.Content["application/json"].Schema
+	if _, ok := body.Properties["nickName"]; !ok || len(body.Properties) != 1 {
+		t.Fatalf("want inline body with nickName, got %+v", body)
+	}
+	if item.Get.Responses["404"].Ref != "#/components/responses/Error404" || item.Get.Responses["default"] == nil {
+		t.Fatalf("want error responses, got %+v", item.Get.Responses)
+	}
+	reasons := doc.Components.Schemas["Error404"].Properties["reason"].Enum
+	if len(reasons) != 1 || reasons[0] != "USER_NOT_FOUND" {
+		t.Fatalf("want USER_NOT_FOUND reason, got %v", reasons)
+	}
+	if reasons = doc.Components.Schemas["Error500"].Properties["reason"].Enum; len(reasons) != 1 || reasons[0] != "UNKNOWN_ERROR" {
+		t.Fatalf("want UNKNOWN_ERROR reason with the default code, got %v", reasons)
+	}
+	user := doc.Components.Schemas["user.v1.User"]
+	if user == nil || user.Properties["roles"].Type != "array" || user.Properties["nickName"] == nil {
+		t.Fatalf("unexpected user schema: %+v", user)
+	}
+	watch := doc.Paths["/users/{id}/watch"]
+	if watch == nil || watch.Get.Responses["200"].Content["text/event-stream"] == nil {
+		t.Fatal("want server streaming method mapped to event stream")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var showVersion = flag.Bool("version", false, "print the version and exit")
+
+func main() {
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-ceres-openapi %v\n", Version)
+		return
+	}
+	var (
+		flags flag.FlagSet
+		opts  options
+	)
+	flags.StringVar(&opts.title, "title", "", "document title, defaults to the first service name")
+	flags.StringVar(&opts.description, "description", "", "document description")
+	flags.StringVar(&opts.version, "doc_version", "1.0.0", "document version")
+	flags.StringVar(&opts.server, "server", "", "server url")
+	flags.BoolVar(&opts.merge, "merge", false, "merge all files into a single document")
+	flags.StringVar(&opts.filename, "filename", "openapi.json", "output filename when merge is enabled")
+	flags.BoolVar(&opts.omitempty, "omitempty", true, "omit methods without http annotations")
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		var g *Generator
+		for _, f := range gen.Files {
+			if !f.Generate || len(f.Services) == 0 {
+				continue
+			}
+			if g == nil || !opts.merge {
+				g = NewGenerator(gen, opts)
+			}
+			g.AddFile(f)
+			if !opts.merge {
+				if err := g.Write(f.GeneratedFilenamePrefix + ".openapi.json"); err != nil {
+					return err
+				}
+			}
+		}
+		if g != nil && opts.merge {
+			return g.Write(opts.filename)
+		}
+		return nil
+	})
+}
@@ -92,7 +92,8 @@ func (g *Generator) genService(service *protogen.Service) {
 		Metadata:    g.file.Desc.Path(),
 	}
 	for _, method := range service.Methods {
-		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		// 客户端流与双向流无法映射为http，服务端流映射为SSE
+		if method.Desc.IsStreamingClient() {
 			continue
 		}
 		g.printf("这是获取到的path:%s", proto.GetExtension(method.Desc.Options(), api.E_Get))
@@ -185,13 +186,14 @@ func (g *Generator) buildMethodDesc(m *protogen.Method, method, path string) *me
 	defer func() { methodSets[m.GoName]++ }()
 
 	return &methodDesc{
-		Name:         m.GoName,
-		OriginalName: string(m.Desc.Name()),
-		Num:          methodSets[m.GoName],
-		Request:      g.writer.QualifiedGoIdent(m.Input.GoIdent),
-		Reply:        g.writer.QualifiedGoIdent(m.Output.GoIdent),
-		Path:         path,
-		Method:       method,
+		Name:            m.GoName,
+		OriginalName:    string(m.Desc.Name()),
+		Num:             methodSets[m.GoName],
+		Request:         g.writer.QualifiedGoIdent(m.Input.GoIdent),
+		Reply:           g.writer.QualifiedGoIdent(m.Output.GoIdent),
+		Path:            path,
+		Method:          method,
+		ServerStreaming: m.Desc.IsStreamingServer(),
 	}
 }
 
@@ -222,7 +224,7 @@ func (g *Generator) P(v ...interface{}) {
 func hasHTTPRule(file *protogen.File) bool {
 	for _, service := range file.Services {
 		for _, method := range service.Methods {
-			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			if method.Desc.IsStreamingClient() {
 				continue
 			}
 			if proto.HasExtension(method.Desc.Options(), api.E_Get) {
@@ -46,6 +46,8 @@ type methodDesc struct {
 	Method       string
 	Body         string
 	ResponseBody string
+	// 服务端流，映射为SSE
+	ServerStreaming bool
 }
 
 func (s *serviceDesc) execute() string {
@@ -9,8 +9,12 @@ const Operation{{$svrType}}{{.OriginalName}} = "/{{$svrName}}/{{.OriginalName}}"
 
 type {{.ServiceType}}HTTPServer interface {
 {{- range .MethodSets}}
+{{- if .ServerStreaming}}
+	{{.Name}}(*{{.Request}}, {{$svrType}}_{{.Name}}Server) error
+{{- else}}
 	{{.Name}}(context.Context, *{{.Request}}) (*{{.Reply}}, error)
 {{- end}}
+{{- end}}
 }
 
 func Register{{.ServiceType}}HTTPServer(s *{{$serverPath}}.Server, srv {{.ServiceType}}HTTPServer) {
@@ -20,6 +24,28 @@ func Register{{.ServiceType}}HTTPServer(s *{{$serverPath}}.Server, srv {{.Servic
 }
 
 {{range .Methods}}
+{{- if .ServerStreaming}}
+func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) func(ctx *{{$serverPath}}.Context) error {
+	return func(ctx *{{$serverPath}}.Context) error {
+		var in {{.Request}}
+		if err := ctx.ShouldBind(&in{{.Body}}); err != nil {
+			return err
+		}
+		{{$serverPath}}.SetOperation(ctx,Operation{{$svrType}}{{.OriginalName}})
+		return ctx.SSEStream(&in, func(stream *{{$serverPath}}.SSEServerStream) error {
+			return srv.{{.Name}}(&in, &_{{$svrType}}_{{.Name}}{{.Num}}_SSE_Server{stream})
+		})
+	}
+}
+
+type _{{$svrType}}_{{.Name}}{{.Num}}_SSE_Server struct {
+	*{{$serverPath}}.SSEServerStream
+}
+
+func (x *_{{$svrType}}_{{.Name}}{{.Num}}_SSE_Server) Send(m *{{.Reply}}) error {
+	return x.SendMsg(m)
+}
+{{- else}}
 func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) func(ctx *{{$serverPath}}.Context) error {
 	return func(ctx *{{$serverPath}}.Context) error {
 		var in {{.Request}}
@@ -38,12 +64,15 @@ func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) fu
 		return ctx.Result(200, reply{{.ResponseBody}})
 	}
 }
+{{- end}}
 {{end}}
 
 type {{.ServiceType}}HTTPClient interface {
 {{- range .MethodSets}}
+{{- if not .ServerStreaming}}
 	{{.Name}}(ctx context.Context, req *{{.Request}}, opts ...{{$clientPath}}.CallOption) (rsp *{{.Reply}}, err error)
 {{- end}}
+{{- end}}
 }
 
 type {{.ServiceType}}HTTPClientImpl struct{
@@ -55,6 +84,7 @@ func New{{.ServiceType}}HTTPClient (client *{{$clientPath}}.Client) {{.ServiceTy
 }
 
 {{range .MethodSets}}
+{{- if not .ServerStreaming}}
 func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Request}}, opts ...{{$clientPath}}.CallOption) (*{{.Reply}}, error) {
 	var out {{.Reply}}
 	path := "{{.Path}}"
@@ -66,4 +96,5 @@ func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Reque
 	}
 	return &out, err
 }
+{{- end}}
 {{end}}
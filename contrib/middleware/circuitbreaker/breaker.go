@@ -0,0 +1,90 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"github.com/go-ceres/ceres/internal/window"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrNotAllowed 熔断器打开时本地拒绝请求返回的错误
+var ErrNotAllowed = errors.ServiceUnavailable("CIRCUIT_BREAKER", "request failed due to circuit breaker triggered")
+
+// Breaker 熔断器接口
+type Breaker interface {
+	// Allow 判断是否允许本次请求通过
+	Allow() error
+	// MarkSuccess 记录一次成功请求
+	MarkSuccess()
+	// MarkFailed 记录一次失败请求
+	MarkFailed()
+}
+
+// sreBreaker Google SRE 自适应限流熔断器
+// 当 请求数 < K * 成功数 时不做限制，否则按 max(0, (请求数 - K*成功数) / (请求数 + 1)) 的概率丢弃请求
+type sreBreaker struct {
+	stat    *window.Window
+	k       float64
+	request int64
+
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newBreaker 根据参数创建熔断器
+func newBreaker(o *Options) Breaker {
+	return &sreBreaker{
+		stat:    window.New(o.Bucket, o.Window),
+		k:       1 / o.Success,
+		request: o.Request,
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Allow 判断是否允许请求通过
+func (b *sreBreaker) Allow() error {
+	accepts, total := b.stat.Summary()
+	requests := b.k * accepts
+	if total < b.request || float64(total) < requests {
+		return nil
+	}
+	dr := math.Max(0, (float64(total)-requests)/float64(total+1))
+	if b.trueOnProba(dr) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// MarkSuccess 记录成功
+func (b *sreBreaker) MarkSuccess() {
+	b.stat.Add(1)
+}
+
+// MarkFailed 记录失败
+func (b *sreBreaker) MarkFailed() {
+	b.stat.Add(0)
+}
+
+func (b *sreBreaker) trueOnProba(proba float64) (truth bool) {
+	b.mu.Lock()
+	truth = b.r.Float64() < proba
+	b.mu.Unlock()
+	return
+}
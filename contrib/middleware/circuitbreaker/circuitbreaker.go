@@ -0,0 +1,109 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+	"sync/atomic"
+)
+
+// Client 客户端熔断中间件，同时按操作与按操作+所选节点维度统计请求结果
+func Client(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	g := &group{
+		opts: o,
+	}
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md, ok := transport.MetadataFromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			operation := md.Operation()
+			breaker := g.get(operation)
+			if err := breaker.Allow(); err != nil {
+				// 本地拒绝同样计入失败，使丢弃比例持续生效
+				breaker.MarkFailed()
+				return nil, err
+			}
+			f := &nodeFilter{group: g, operation: operation}
+			reply, err := handler(transport.NewNodeFilterContext(ctx, f.filter), req)
+			// 对冲请求中其他尝试成功时不覆盖结果
+			if err != nil && f.rejected.Load() {
+				err = ErrNotAllowed
+			}
+			di := transport.DoneInfo{Err: err}
+			if p, ok := transport.FromPeerContext(ctx); ok && p.Node != nil {
+				g.done(g.get(nodeKey(operation, p.Node)), di)
+			}
+			g.done(breaker, di)
+			return reply, err
+		}
+	}
+}
+
+// group 熔断器集合
+type group struct {
+	opts     *Options
+	breakers sync.Map
+}
+
+// get 获取指定键的熔断器，不存在则创建
+func (g *group) get(key string) Breaker {
+	if b, ok := g.breakers.Load(key); ok {
+		return b.(Breaker)
+	}
+	b, _ := g.breakers.LoadOrStore(key, g.opts.builder(g.opts))
+	return b.(Breaker)
+}
+
+// done 根据调用结果更新熔断器，失败判定与负载均衡节点健康度保持一致
+func (g *group) done(b Breaker, di transport.DoneInfo) {
+	if transport.IsFailure(di.Err, g.opts.errHandler) {
+		b.MarkFailed()
+		return
+	}
+	b.MarkSuccess()
+}
+
+// nodeFilter 过滤掉熔断器已打开的节点，对冲请求时会被多个尝试并发调用
+type nodeFilter struct {
+	group     *group
+	operation string
+	rejected  atomic.Bool
+}
+
+func (f *nodeFilter) filter(_ context.Context, nodes []transport.Node) []transport.Node {
+	allowed := make([]transport.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if f.group.get(nodeKey(f.operation, n)).Allow() == nil {
+			allowed = append(allowed, n)
+		}
+	}
+	if len(allowed) == 0 && len(nodes) > 0 {
+		f.rejected.Store(true)
+	}
+	return allowed
+}
+
+func nodeKey(operation string, node transport.Node) string {
+	return operation + "@" + node.Address()
+}
@@ -0,0 +1,109 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetadata struct {
+	operation string
+}
+
+func (m *testMetadata) Kind() transport.Kind            { return "test" }
+func (m *testMetadata) Endpoint() string                { return "" }
+func (m *testMetadata) Operation() string               { return m.operation }
+func (m *testMetadata) RequestHeader() transport.Header { return nil }
+func (m *testMetadata) ReplyHeader() transport.Header   { return nil }
+
+func TestBreaker(t *testing.T) {
+	o := DefaultOptions()
+	o.Request = 10
+	b := newBreaker(o)
+	for i := 0; i < 100; i++ {
+		b.MarkFailed()
+	}
+	rejected := 0
+	for i := 0; i < 100; i++ {
+		if b.Allow() != nil {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Fatal("breaker should reject requests after continuous failures")
+	}
+
+	b = newBreaker(o)
+	for i := 0; i < 100; i++ {
+		b.MarkSuccess()
+	}
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("breaker should allow requests: %v", err)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	mw := Client(WithRequest(10), WithWindow(time.Second))
+	unavailable := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	}
+	badRequest := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.BadRequest("BAD_REQUEST", "bad request")
+	}
+	ctx := transport.NewMetadataClientContext(context.Background(), &testMetadata{operation: "/test.Test/Bad"})
+	for i := 0; i < 100; i++ {
+		_, _ = mw(badRequest)(ctx, nil)
+	}
+	if _, err := mw(badRequest)(ctx, nil); !errors.IsBadRequest(err) {
+		t.Fatalf("client errors should not open the breaker, got: %v", err)
+	}
+
+	ctx = transport.NewMetadataClientContext(context.Background(), &testMetadata{operation: "/test.Test/Unavailable"})
+	rejected := 0
+	for i := 0; i < 200; i++ {
+		if _, err := mw(unavailable)(ctx, nil); errors.Is(err, ErrNotAllowed) {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Fatal("breaker should reject requests locally")
+	}
+}
+
+// TestNodeFilterConcurrent 对冲请求时节点过滤器会被并发调用
+func TestNodeFilterConcurrent(t *testing.T) {
+	f := &nodeFilter{group: &group{opts: DefaultOptions()}, operation: "/test.Test/Hedge"}
+	nodes := []transport.Node{transport.NewNode("http", "127.0.0.1:8000", nil)}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.filter(context.Background(), nodes)
+		}()
+	}
+	wg.Wait()
+	if f.rejected.Load() {
+		t.Fatal("healthy node should not be rejected")
+	}
+}
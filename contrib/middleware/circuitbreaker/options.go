@@ -0,0 +1,84 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import "time"
+
+// Option 熔断器中间件参数
+type Option func(o *Options)
+
+// Options 熔断器中间件参数信息
+type Options struct {
+	Success    float64                  `json:"success"` // 期望的最低成功率，K = 1 / success，默认值：0.6
+	Request    int64                    `json:"request"` // 窗口内请求数小于该值时不熔断，默认值：100
+	Bucket     int                      `json:"bucket"`  // 滑动窗口桶数量，默认值：10
+	Window     time.Duration            `json:"window"`  // 滑动窗口时长，默认值：3s
+	errHandler func(err error) bool     // 额外的失败判定，与 transport.DefaultWeightNodeBuilder.ErrHandler 保持一致
+	builder    func(o *Options) Breaker // 熔断器构建方法
+}
+
+// DefaultOptions 默认参数
+func DefaultOptions() *Options {
+	return &Options{
+		Success: 0.6,
+		Request: 100,
+		Bucket:  10,
+		Window:  3 * time.Second,
+		builder: newBreaker,
+	}
+}
+
+// WithSuccess 设置期望的最低成功率
+func WithSuccess(success float64) Option {
+	return func(o *Options) {
+		o.Success = success
+	}
+}
+
+// WithRequest 设置触发熔断的最少请求数
+func WithRequest(request int64) Option {
+	return func(o *Options) {
+		o.Request = request
+	}
+}
+
+// WithBucket 设置滑动窗口桶数量
+func WithBucket(bucket int) Option {
+	return func(o *Options) {
+		o.Bucket = bucket
+	}
+}
+
+// WithWindow 设置滑动窗口时长
+func WithWindow(window time.Duration) Option {
+	return func(o *Options) {
+		o.Window = window
+	}
+}
+
+// WithErrHandler 设置额外的失败判定方法，应与负载均衡节点构建器的 ErrHandler 一致
+func WithErrHandler(handler func(err error) bool) Option {
+	return func(o *Options) {
+		o.errHandler = handler
+	}
+}
+
+// WithBreakerBuilder 自定义熔断器实现
+func WithBreakerBuilder(builder func(o *Options) Breaker) Option {
+	return func(o *Options) {
+		o.builder = builder
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// GlobalPrefix 全局元数据前缀，沿整条调用链传递
+	GlobalPrefix = "x-md-global-"
+	// LocalPrefix 本地元数据前缀，只传递到下一跳
+	LocalPrefix = "x-md-local-"
+)
+
+// Metadata 跨服务传递的元数据，键统一为小写
+type Metadata map[string]string
+
+// New 创建元数据
+func New(mds ...map[string]string) Metadata {
+	md := Metadata{}
+	for _, m := range mds {
+		for k, v := range m {
+			md.Set(k, v)
+		}
+	}
+	return md
+}
+
+// Get 获取元数据的值
+func (m Metadata) Get(key string) string {
+	return m[strings.ToLower(key)]
+}
+
+// Set 设置元数据的值
+func (m Metadata) Set(key, value string) {
+	if key == "" || value == "" {
+		return
+	}
+	m[strings.ToLower(key)] = value
+}
+
+// Range 遍历元数据，返回false时停止遍历
+func (m Metadata) Range(f func(k, v string) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+// Clone 复制元数据
+func (m Metadata) Clone() Metadata {
+	md := make(Metadata, len(m))
+	for k, v := range m {
+		md[k] = v
+	}
+	return md
+}
+
+type (
+	serverMetadataKey struct{}
+	clientMetadataKey struct{}
+)
+
+// NewServerContext 创建携带服务端元数据的上下文
+func NewServerContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, serverMetadataKey{}, md)
+}
+
+// FromServerContext 获取上游传入的元数据
+func FromServerContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(serverMetadataKey{}).(Metadata)
+	return md, ok
+}
+
+// NewClientContext 创建携带客户端元数据的上下文
+func NewClientContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, clientMetadataKey{}, md)
+}
+
+// FromClientContext 获取将要发送给下游的元数据
+func FromClientContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(clientMetadataKey{}).(Metadata)
+	return md, ok
+}
+
+// AppendToClientContext 追加发送给下游的元数据，kv 为键值对，不会修改父上下文中的元数据
+func AppendToClientContext(ctx context.Context, kv ...string) context.Context {
+	if len(kv)%2 == 1 {
+		panic("metadata: AppendToClientContext got an odd number of input pairs")
+	}
+	md, _ := FromClientContext(ctx)
+	md = md.Clone()
+	for i := 0; i < len(kv); i += 2 {
+		md.Set(kv[i], kv[i+1])
+	}
+	return NewClientContext(ctx, md)
+}
+
+// MergeToClientContext 合并发送给下游的元数据
+func MergeToClientContext(ctx context.Context, cmd Metadata) context.Context {
+	md, _ := FromClientContext(ctx)
+	md = md.Clone()
+	for k, v := range cmd {
+		md.Set(k, v)
+	}
+	return NewClientContext(ctx, md)
+}
+
+// Value 获取元数据的值，优先取本服务设置的值，其次取上游传入的值
+func Value(ctx context.Context, key string) string {
+	if md, ok := FromClientContext(ctx); ok {
+		if v := md.Get(key); v != "" {
+			return v
+		}
+	}
+	if md, ok := FromServerContext(ctx); ok {
+		return md.Get(key)
+	}
+	return ""
+}
+
+// Global 获取全局元数据的值，name 不包含前缀
+func Global(ctx context.Context, name string) string {
+	return Value(ctx, GlobalPrefix+name)
+}
+
+// Local 获取上游传入的本地元数据的值，name 不包含前缀
+func Local(ctx context.Context, name string) string {
+	return Value(ctx, LocalPrefix+name)
+}
+
+// WithGlobal 设置全局元数据，name 不包含前缀
+func WithGlobal(ctx context.Context, name, value string) context.Context {
+	return AppendToClientContext(ctx, GlobalPrefix+name, value)
+}
+
+// WithLocal 设置只传递到下一跳的元数据，name 不包含前缀
+func WithLocal(ctx context.Context, name, value string) context.Context {
+	return AppendToClientContext(ctx, LocalPrefix+name, value)
+}
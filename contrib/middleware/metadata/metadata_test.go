@@ -0,0 +1,126 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// httpHeader 模拟http请求头，键会被规范为首字母大写
+type httpHeader map[string]string
+
+func (h httpHeader) Get(key string) string { return h[textproto.CanonicalMIMEHeaderKey(key)] }
+func (h httpHeader) Set(key, value string) { h[textproto.CanonicalMIMEHeaderKey(key)] = value }
+func (h httpHeader) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// grpcHeader 模拟grpc元数据，键会被转为小写
+type grpcHeader map[string]string
+
+func (h grpcHeader) Get(key string) string { return h[strings.ToLower(key)] }
+func (h grpcHeader) Set(key, value string) { h[strings.ToLower(key)] = value }
+func (h grpcHeader) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockMetadata struct {
+	request transport.Header
+}
+
+func (m *mockMetadata) Kind() transport.Kind            { return "mock" }
+func (m *mockMetadata) Endpoint() string                { return "mock://127.0.0.1" }
+func (m *mockMetadata) Operation() string               { return "/mock" }
+func (m *mockMetadata) RequestHeader() transport.Header { return m.request }
+func (m *mockMetadata) ReplyHeader() transport.Header   { return grpcHeader{} }
+
+// hop 模拟一次服务端接收请求，并在处理过程中调用下游
+func hop(incoming transport.Header, outgoing transport.Header, fn func(ctx context.Context) context.Context) {
+	client := Client()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	server := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if fn != nil {
+			ctx = fn(ctx)
+		}
+		return client(transport.NewMetadataClientContext(ctx, &mockMetadata{request: outgoing}), req)
+	})
+	_, _ = server(transport.NewMetadataServerContext(context.Background(), &mockMetadata{request: incoming}), nil)
+}
+
+func TestPropagation(t *testing.T) {
+	// http -> grpc -> http
+	first := httpHeader{}
+	first.Set("X-Md-Global-Tenant", "t1")
+	first.Set("X-Md-Local-Caller", "gateway")
+	first.Set("Authorization", "secret")
+
+	second := grpcHeader{}
+	var tenant, caller string
+	hop(first, second, func(ctx context.Context) context.Context {
+		tenant, caller = Global(ctx, "tenant"), Local(ctx, "caller")
+		ctx = WithGlobal(ctx, "user", "u1")
+		return WithLocal(ctx, "caller", "order")
+	})
+	if tenant != "t1" || caller != "gateway" {
+		t.Fatalf("unexpected server metadata tenant=%q caller=%q", tenant, caller)
+	}
+	if second.Get("x-md-global-tenant") != "t1" || second.Get("x-md-global-user") != "u1" {
+		t.Fatalf("global metadata not propagated: %v", second)
+	}
+	if second.Get("x-md-local-caller") != "order" || second.Get("authorization") != "" {
+		t.Fatalf("unexpected outgoing metadata: %v", second)
+	}
+
+	third := httpHeader{}
+	hop(second, third, nil)
+	if third.Get("x-md-global-tenant") != "t1" || third.Get("x-md-global-user") != "u1" {
+		t.Fatalf("global metadata not propagated: %v", third)
+	}
+	if third.Get("x-md-local-caller") != "" {
+		t.Fatalf("local metadata must stop after one hop: %v", third)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := AppendToClientContext(context.Background(), "X-Md-Global-Flag", "on")
+	child := AppendToClientContext(ctx, "x-md-global-flag", "off", "x-md-global-env", "gray")
+	if Global(ctx, "flag") != "on" {
+		t.Fatal("parent context must not be modified")
+	}
+	if Global(child, "flag") != "off" || Global(child, "env") != "gray" {
+		t.Fatalf("unexpected client metadata")
+	}
+	ctx = NewServerContext(context.Background(), New(map[string]string{"X-Md-Global-Flag": "server"}))
+	if Value(ctx, "x-md-global-flag") != "server" {
+		t.Fatal("want server metadata")
+	}
+	if Value(MergeToClientContext(ctx, Metadata{"x-md-global-flag": "client"}), "x-md-global-flag") != "client" {
+		t.Fatal("client metadata must take precedence")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"strings"
+)
+
+// Server 服务端元数据中间件，从请求头中提取指定前缀的元数据写入上下文
+func Server(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.MetadataFromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			md := Metadata{}
+			header := tr.RequestHeader()
+			for _, k := range header.Keys() {
+				// http请求头会被规范为首字母大写，统一转为小写；同时复制字符串，避免引用底层缓冲区
+				key := strings.ToLower(k)
+				if hasPrefix(key, o.prefix) {
+					md.Set(string([]byte(key)), string([]byte(header.Get(k))))
+				}
+			}
+			return handler(NewServerContext(ctx, md), req)
+		}
+	}
+}
+
+// Client 客户端元数据中间件，将固定元数据、上游传入的全局元数据与本服务设置的元数据写入请求头
+func Client(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.MetadataFromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			header := tr.RequestHeader()
+			for k, v := range o.constants {
+				header.Set(k, v)
+			}
+			if md, ok := FromServerContext(ctx); ok {
+				for k, v := range md {
+					if hasPrefix(k, o.propagate) {
+						header.Set(k, v)
+					}
+				}
+			}
+			if md, ok := FromClientContext(ctx); ok {
+				for k, v := range md {
+					header.Set(k, v)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
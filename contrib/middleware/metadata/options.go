@@ -0,0 +1,74 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import "strings"
+
+type Option func(o *Options)
+
+// Options 元数据传递配置
+type Options struct {
+	prefix    []string // 服务端从请求头中提取的前缀
+	propagate []string // 客户端继续向下游传递的上游元数据前缀
+	constants Metadata // 客户端固定发送的元数据
+}
+
+// DefaultOptions 默认配置，服务端提取全局与本地元数据，客户端只继续传递全局元数据
+func DefaultOptions() *Options {
+	return &Options{
+		prefix:    []string{GlobalPrefix, LocalPrefix},
+		propagate: []string{GlobalPrefix},
+	}
+}
+
+// WithPrefix 设置服务端从请求头中提取的前缀
+func WithPrefix(prefix ...string) Option {
+	return func(o *Options) {
+		o.prefix = lower(prefix)
+	}
+}
+
+// WithPropagatedPrefix 设置客户端继续向下游传递的上游元数据前缀
+func WithPropagatedPrefix(prefix ...string) Option {
+	return func(o *Options) {
+		o.propagate = lower(prefix)
+	}
+}
+
+// WithConstants 设置客户端固定发送的元数据
+func WithConstants(md Metadata) Option {
+	return func(o *Options) {
+		o.constants = New(md)
+	}
+}
+
+func lower(prefix []string) []string {
+	res := make([]string, 0, len(prefix))
+	for _, p := range prefix {
+		res = append(res, strings.ToLower(p))
+	}
+	return res
+}
+
+// hasPrefix 判断键是否包含指定前缀，键需为小写
+func hasPrefix(key string, prefix []string) bool {
+	for _, p := range prefix {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/go-ceres/ceres/pkg/transport/http"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler 指标暴露路由方法
+func Handler(opts ...Option) http.HandlerFunc {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return http.WrapHandler(promhttp.HandlerFor(o.gatherer, promhttp.HandlerOpts{}))
+}
+
+// Register 在http服务上注册指标暴露路由，默认为 /metrics
+func Register(srv *http.Server, opts ...Option) {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	srv.GET(o.path, Handler(opts...))
+}
@@ -0,0 +1,116 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+const (
+	labelKind      = "kind"
+	labelOperation = "operation"
+	labelCode      = "code"
+)
+
+// requestMetrics 请求指标
+type requestMetrics struct {
+	requests *prometheus.CounterVec
+	seconds  *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+}
+
+// newRequestMetrics 创建并注册请求指标，side 为 server 或 client
+func newRequestMetrics(side string, o *Options) *requestMetrics {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: o.namespace,
+		Subsystem: side,
+		Name:      "requests_total",
+		Help:      "Total number of requests.",
+	}, []string{labelKind, labelOperation, labelCode})
+	seconds := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: o.namespace,
+		Subsystem: side,
+		Name:      "request_duration_seconds",
+		Help:      "Request latency in seconds.",
+		Buckets:   o.buckets,
+	}, []string{labelKind, labelOperation, labelCode})
+	inflight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: o.namespace,
+		Subsystem: side,
+		Name:      "requests_in_flight",
+		Help:      "Number of requests currently being processed.",
+	}, []string{labelKind, labelOperation})
+	return &requestMetrics{
+		requests: register(o.registerer, requests).(*prometheus.CounterVec),
+		seconds:  register(o.registerer, seconds).(*prometheus.HistogramVec),
+		inflight: register(o.registerer, inflight).(*prometheus.GaugeVec),
+	}
+}
+
+// register 注册指标，已注册时复用已有指标，便于多个服务共享
+func register(registerer prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := registerer.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Server 服务端指标中间件，统计请求数、耗时与处理中的请求数
+func Server(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newRequestMetrics("server", o).middleware(transport.MetadataFromServerContext)
+}
+
+// Client 客户端指标中间件，统计请求数、耗时与处理中的请求数
+func Client(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newRequestMetrics("client", o).middleware(transport.MetadataFromClientContext)
+}
+
+func (m *requestMetrics) middleware(fromContext func(ctx context.Context) (transport.Metadata, bool)) transport.Middleware {
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md, ok := fromContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			kind, operation := md.Kind().String(), md.Operation()
+			inflight := m.inflight.WithLabelValues(kind, operation)
+			inflight.Inc()
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			inflight.Dec()
+			code := strconv.Itoa(int(errors.Code(err)))
+			m.requests.WithLabelValues(kind, operation, code).Inc()
+			m.seconds.WithLabelValues(kind, operation, code).Observe(time.Since(start).Seconds())
+			return reply, err
+		}
+	}
+}
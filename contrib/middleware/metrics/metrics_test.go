@@ -0,0 +1,124 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"io"
+	"strings"
+	"testing"
+)
+
+type mockMetadata struct {
+	operation string
+}
+
+func (m *mockMetadata) Kind() transport.Kind            { return "grpc" }
+func (m *mockMetadata) Endpoint() string                { return "" }
+func (m *mockMetadata) Operation() string               { return m.operation }
+func (m *mockMetadata) RequestHeader() transport.Header { return nil }
+func (m *mockMetadata) ReplyHeader() transport.Header   { return nil }
+
+func TestServer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	ctx := transport.NewMetadataServerContext(context.Background(), &mockMetadata{operation: "/user.User/Get"})
+	ok := Server(WithRegistry(registry))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	fail := Server(WithRegistry(registry))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user not found")
+	})
+	_, _ = ok(ctx, nil)
+	_, _ = ok(ctx, nil)
+	_, _ = fail(ctx, nil)
+
+	expected := `
+# HELP ceres_server_requests_total Total number of requests.
+# TYPE ceres_server_requests_total counter
+ceres_server_requests_total{code="200",kind="grpc",operation="/user.User/Get"} 2
+ceres_server_requests_total{code="404",kind="grpc",operation="/user.User/Get"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "ceres_server_requests_total"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(registry, "ceres_server_request_duration_seconds"); n != 2 {
+		t.Fatalf("want 2 histograms, got %d", n)
+	}
+}
+
+func TestSelectorBuilder(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	builder := NewSelectorBuilder(&transport.DefaultSelectorBuilder{
+		BalancerBuilder:  &transport.DefaultBalancerBuilder{},
+		WightNodeBuilder: &transport.DefaultWeightNodeBuilder{},
+	}, WithRegistry(registry))
+	s := builder.Build()
+	s.Store([]transport.Node{
+		transport.NewNode("grpc", "127.0.0.1:9000", &transport.ServiceInfo{Name: "user"}),
+	})
+	_, done, err := s.Select(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done(context.Background(), transport.DoneInfo{})
+
+	expected := `
+# HELP ceres_selector_picked_total Total number of times a node was picked.
+# TYPE ceres_selector_picked_total counter
+ceres_selector_picked_total{node="127.0.0.1:9000",service="user"} 1
+# HELP ceres_selector_node_success_ratio Moving average success ratio of the node.
+# TYPE ceres_selector_node_success_ratio gauge
+ceres_selector_node_success_ratio{node="127.0.0.1:9000",service="user"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "ceres_selector_picked_total", "ceres_selector_node_success_ratio"); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后不再导出节点指标
+	if err := s.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(registry, "ceres_selector_node_success_ratio", "ceres_selector_picked_total"); n != 0 {
+		t.Fatalf("want no node metrics after close, got %d", n)
+	}
+}
+
+// TestSelectorPickedRemoved 节点移除后删除选择次数，仍被其他选择器引用时保留
+func TestSelectorPickedRemoved(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	builder := NewSelectorBuilder(&transport.DefaultSelectorBuilder{
+		BalancerBuilder:  &transport.DefaultBalancerBuilder{},
+		WightNodeBuilder: &transport.DefaultWeightNodeBuilder{},
+	}, WithRegistry(registry))
+	node := transport.NewNode("grpc", "127.0.0.1:9000", &transport.ServiceInfo{Name: "user"})
+	s1, s2 := builder.Build(), builder.Build()
+	s1.Store([]transport.Node{node})
+	s2.Store([]transport.Node{node})
+	if _, _, err := s1.Select(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s1.Store([]transport.Node{transport.NewNode("grpc", "127.0.0.1:9001", &transport.ServiceInfo{Name: "user"})})
+	if n := testutil.CollectAndCount(registry, "ceres_selector_picked_total"); n != 1 {
+		t.Fatalf("want picked kept while referenced, got %d", n)
+	}
+	s2.Store(nil)
+	if n := testutil.CollectAndCount(registry, "ceres_selector_picked_total"); n != 0 {
+		t.Fatalf("want picked removed, got %d", n)
+	}
+}
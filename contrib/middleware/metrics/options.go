@@ -0,0 +1,85 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Option func(o *Options)
+
+// Options 指标配置
+type Options struct {
+	namespace  string                // 指标命名空间
+	path       string                // 指标暴露路由
+	buckets    []float64             // 耗时直方图分桶，单位秒
+	registerer prometheus.Registerer // 指标注册器
+	gatherer   prometheus.Gatherer   // 指标收集器
+}
+
+// DefaultOptions 默认配置，指标注册到prometheus默认注册器
+func DefaultOptions() *Options {
+	return &Options{
+		namespace:  "ceres",
+		path:       "/metrics",
+		buckets:    []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		registerer: prometheus.DefaultRegisterer,
+		gatherer:   prometheus.DefaultGatherer,
+	}
+}
+
+// WithNamespace 设置指标命名空间
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.namespace = namespace
+	}
+}
+
+// WithPath 设置指标暴露的路由
+func WithPath(path string) Option {
+	return func(o *Options) {
+		o.path = path
+	}
+}
+
+// WithBuckets 设置耗时直方图分桶
+func WithBuckets(buckets ...float64) Option {
+	return func(o *Options) {
+		o.buckets = buckets
+	}
+}
+
+// WithRegisterer 设置指标注册器
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(o *Options) {
+		o.registerer = registerer
+	}
+}
+
+// WithGatherer 设置指标收集器
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return func(o *Options) {
+		o.gatherer = gatherer
+	}
+}
+
+// WithRegistry 同时设置指标注册器与收集器
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(o *Options) {
+		o.registerer = registry
+		o.gatherer = registry
+	}
+}
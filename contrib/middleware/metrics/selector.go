@@ -0,0 +1,235 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"sync"
+)
+
+const (
+	labelService = "service"
+	labelNode    = "node"
+)
+
+// NewSelectorBuilder 包装选择器构建器，统计节点被选中的次数，
+// 并导出节点的平均延迟、成功率与处理中请求数，客户端关闭时选择器随之关闭并停止导出，
+// 使用方式: transport.SetSelectorBuilder(metrics.NewSelectorBuilder(transport.GetSelectorBuilder()))
+func NewSelectorBuilder(builder transport.SelectorBuilder, opts ...Option) transport.SelectorBuilder {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &selectorBuilder{
+		builder:   builder,
+		collector: register(o.registerer, newSelectorCollector(o)).(*selectorCollector),
+	}
+}
+
+var (
+	_ transport.BalancerSelectorBuilder = (*selectorBuilder)(nil)
+	_ transport.OutlierSelectorBuilder  = (*selectorBuilder)(nil)
+)
+
+type selectorBuilder struct {
+	builder   transport.SelectorBuilder
+	collector *selectorCollector
+}
+
+func (b *selectorBuilder) Build() transport.Selector {
+	s := &selector{
+		Selector:  b.builder.Build(),
+		collector: b.collector,
+	}
+	b.collector.add(s)
+	return s
+}
+
+// WithBalancer 返回使用指定负载均衡器的选择器构建器，被包装的构建器不支持时保持不变
+func (b *selectorBuilder) WithBalancer(name string, builder transport.BalancerBuilder) transport.SelectorBuilder {
+	inner := b.builder
+	if sb, ok := inner.(transport.BalancerSelectorBuilder); ok {
+		inner = sb.WithBalancer(name, builder)
+	}
+	return &selectorBuilder{
+		builder:   inner,
+		collector: b.collector,
+	}
+}
+
+// WithOutlier 返回开启异常节点检测的选择器构建器，被包装的构建器不支持时保持不变
+func (b *selectorBuilder) WithOutlier(opts *transport.OutlierOptions) transport.SelectorBuilder {
+	inner := b.builder
+	if sb, ok := inner.(transport.OutlierSelectorBuilder); ok {
+		inner = sb.WithOutlier(opts)
+	}
+	return &selectorBuilder{
+		builder:   inner,
+		collector: b.collector,
+	}
+}
+
+// selector 统计节点选择次数的选择器
+type selector struct {
+	transport.Selector
+	collector *selectorCollector
+	mu        sync.Mutex
+	keys      map[[2]string]struct{} // 当前节点的指标标签
+}
+
+// Store 更新节点，不再被任何选择器引用的节点删除选择次数指标
+func (s *selector) Store(nodes []transport.Node) {
+	keys := make(map[[2]string]struct{}, len(nodes))
+	for _, node := range nodes {
+		keys[[2]string{serviceName(node), node.Address()}] = struct{}{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Selector.Store(nodes)
+	s.collector.retain(s.keys, keys)
+	s.keys = keys
+}
+
+func (s *selector) Select(ctx context.Context, opts ...transport.SelectOption) (transport.Node, transport.DoneFunc, error) {
+	node, done, err := s.Selector.Select(ctx, opts...)
+	if err == nil {
+		s.collector.picked.WithLabelValues(serviceName(node), node.Address()).Inc()
+	}
+	return node, done, err
+}
+
+// Close 停止导出选择器的节点指标，被包装的选择器实现 io.Closer 时一并关闭
+func (s *selector) Close() error {
+	s.collector.remove(s)
+	s.mu.Lock()
+	s.collector.retain(s.keys, nil)
+	s.keys = nil
+	s.mu.Unlock()
+	if closer, ok := s.Selector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// nodesGetter 可获取运行时节点的选择器，如 transport.DefaultSelector
+type nodesGetter interface {
+	Nodes() []transport.IWeightedNode
+}
+
+// selectorCollector 选择器指标收集器
+type selectorCollector struct {
+	mu        sync.RWMutex
+	selectors map[*selector]struct{}
+	refs      map[[2]string]int // 引用节点的选择器数量，多个客户端可能连接同一节点
+	picked    *prometheus.CounterVec
+	lag       *prometheus.Desc
+	success   *prometheus.Desc
+	inflight  *prometheus.Desc
+}
+
+func newSelectorCollector(o *Options) *selectorCollector {
+	labels := []string{labelService, labelNode}
+	return &selectorCollector{
+		selectors: make(map[*selector]struct{}),
+		refs:      make(map[[2]string]int),
+		picked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "selector",
+			Name:      "picked_total",
+			Help:      "Total number of times a node was picked.",
+		}, labels),
+		lag:      prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "selector", "node_lag_seconds"), "Moving average latency of the node.", labels, nil),
+		success:  prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "selector", "node_success_ratio"), "Moving average success ratio of the node.", labels, nil),
+		inflight: prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "selector", "node_inflight"), "Number of in-flight requests of the node.", labels, nil),
+	}
+}
+
+func (c *selectorCollector) add(s *selector) {
+	c.mu.Lock()
+	c.selectors[s] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (c *selectorCollector) remove(s *selector) {
+	c.mu.Lock()
+	delete(c.selectors, s)
+	c.mu.Unlock()
+}
+
+// retain 更新节点引用计数，删除没有选择器引用的节点的选择次数
+func (c *selectorCollector) retain(old, keys map[[2]string]struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range keys {
+		if _, ok := old[key]; !ok {
+			c.refs[key]++
+		}
+	}
+	for key := range old {
+		if _, ok := keys[key]; ok {
+			continue
+		}
+		if c.refs[key]--; c.refs[key] <= 0 {
+			delete(c.refs, key)
+			c.picked.DeleteLabelValues(key[0], key[1])
+		}
+	}
+}
+
+func (c *selectorCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.picked.Describe(ch)
+	ch <- c.lag
+	ch <- c.success
+	ch <- c.inflight
+}
+
+func (c *selectorCollector) Collect(ch chan<- prometheus.Metric) {
+	c.picked.Collect(ch)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// 多个客户端可能连接同一节点，只导出一次
+	seen := make(map[[2]string]struct{})
+	for s := range c.selectors {
+		getter, ok := s.Selector.(nodesGetter)
+		if !ok {
+			continue
+		}
+		for _, node := range getter.Nodes() {
+			stats, ok := node.(transport.NodeStats)
+			if !ok {
+				continue
+			}
+			key := [2]string{serviceName(node), node.Address()}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, stats.Lag().Seconds(), key[0], key[1])
+			ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, stats.Success(), key[0], key[1])
+			ch <- prometheus.MustNewConstMetric(c.inflight, prometheus.GaugeValue, float64(stats.Inflight()), key[0], key[1])
+		}
+	}
+}
+
+func serviceName(node transport.Node) string {
+	if info := node.ServiceInfo(); info != nil {
+		return info.Name
+	}
+	return ""
+}
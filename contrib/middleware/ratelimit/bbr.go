@@ -0,0 +1,175 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/go-ceres/ceres/internal/window"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLimitExceed 服务过载时拒绝请求返回的错误
+var ErrLimitExceed = errors.TooManyRequests("RATELIMIT", "service unavailable due to rate limit exceeded")
+
+// Priority 请求优先级，优先级越低越先被丢弃
+type Priority int
+
+const (
+	PriorityLow      Priority = iota // 低优先级，在估算容量的70%处开始丢弃
+	PriorityNormal                   // 普通优先级，在估算容量处开始丢弃
+	PriorityHigh                     // 高优先级，在估算容量的130%处开始丢弃
+	PriorityCritical                 // 关键请求，永不丢弃
+)
+
+// factor 各优先级对最大并发的放大系数
+func (p Priority) factor() float64 {
+	switch p {
+	case PriorityLow:
+		return 0.7
+	case PriorityHigh:
+		return 1.3
+	default:
+		return 1
+	}
+}
+
+// DoneFunc 请求完成回调
+type DoneFunc func()
+
+// Limiter 限流器接口
+type Limiter interface {
+	// Allow 判断指定优先级的请求是否允许通过，通过时返回完成回调
+	Allow(priority Priority) (DoneFunc, error)
+}
+
+// bbr 参考TCP BBR拥塞控制的自适应限流器
+// 当cpu使用率超过阈值(或刚发生过丢弃)时，若 在途请求数 > 窗口内最大通过数 * 最小响应时间 则丢弃请求，
+// cpu阈值小于等于0时不检测cpu，始终按并发判断
+type bbr struct {
+	opts            *Options
+	passStat        *window.Window
+	rtStat          *window.Window
+	inFlight        int64
+	bucketPerSecond float64
+	prevDropTime    int64 // 上一次丢弃请求的时间，相对于start
+	start           time.Time
+}
+
+// NewLimiter 创建bbr限流器
+func NewLimiter(opts ...Option) Limiter {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newLimiter(o)
+}
+
+func newLimiter(o *Options) *bbr {
+	if o.CPUThreshold > 0 && o.cpuGetter == nil {
+		startCPUSampler()
+		o.cpuGetter = loadCPUUsage
+	}
+	passStat := window.New(o.Bucket, o.Window)
+	return &bbr{
+		opts:            o,
+		passStat:        passStat,
+		rtStat:          window.New(o.Bucket, o.Window),
+		bucketPerSecond: float64(time.Second) / float64(passStat.Interval()),
+		start:           time.Now(),
+	}
+}
+
+// maxPass 窗口内单个桶的最大通过数
+func (l *bbr) maxPass() int64 {
+	var result float64 = 1
+	l.passStat.Range(true, func(b window.Bucket) bool {
+		if b.Sum > result {
+			result = b.Sum
+		}
+		return true
+	})
+	return int64(result)
+}
+
+// minRT 窗口内单个桶的最小平均响应时间，单位：毫秒，至少为1，避免亚毫秒响应时估算并发为0
+func (l *bbr) minRT() int64 {
+	result := math.MaxFloat64
+	l.rtStat.Range(true, func(b window.Bucket) bool {
+		if b.Count > 0 && b.Avg() < result {
+			result = b.Avg()
+		}
+		return true
+	})
+	if result == math.MaxFloat64 {
+		return 1
+	}
+	if rt := int64(math.Ceil(result)); rt > 1 {
+		return rt
+	}
+	return 1
+}
+
+// maxInFlight 估算的最大在途请求数
+func (l *bbr) maxInFlight() int64 {
+	return int64(math.Floor(float64(l.maxPass()*l.minRT())*l.bucketPerSecond/1000.0 + 0.5))
+}
+
+func (l *bbr) shouldDrop(priority Priority) bool {
+	if priority >= PriorityCritical {
+		return false
+	}
+	now := time.Since(l.start)
+	if l.opts.CPUThreshold > 0 && l.opts.cpuGetter != nil && l.opts.cpuGetter() < l.opts.CPUThreshold {
+		prevDropTime := atomic.LoadInt64(&l.prevDropTime)
+		if prevDropTime == 0 {
+			return false
+		}
+		// 冷却期内仍然按并发判断，防止抖动
+		if now-time.Duration(prevDropTime) <= l.opts.CoolDown {
+			return l.overload(priority)
+		}
+		atomic.StoreInt64(&l.prevDropTime, 0)
+		return false
+	}
+	drop := l.overload(priority)
+	if drop {
+		if atomic.LoadInt64(&l.prevDropTime) == 0 {
+			atomic.StoreInt64(&l.prevDropTime, int64(now))
+		}
+	}
+	return drop
+}
+
+func (l *bbr) overload(priority Priority) bool {
+	inFlight := atomic.LoadInt64(&l.inFlight)
+	return inFlight > 1 && float64(inFlight) > float64(l.maxInFlight())*priority.factor()
+}
+
+// Allow 判断请求是否允许通过
+func (l *bbr) Allow(priority Priority) (DoneFunc, error) {
+	if l.shouldDrop(priority) {
+		return nil, ErrLimitExceed
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	start := time.Now()
+	return func() {
+		l.rtStat.Add(float64(time.Since(start).Milliseconds()))
+		atomic.AddInt64(&l.inFlight, -1)
+		l.passStat.Add(1)
+	}, nil
+}
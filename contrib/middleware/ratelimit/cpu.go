@@ -0,0 +1,71 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	cpuUsage  int64 // cpu使用率，取值范围：0~1000
+	cpuOnce   sync.Once
+	cpuDecay  = 0.95
+	cpuPeriod = 500 * time.Millisecond
+)
+
+// cpuReader 读取累计的cpu繁忙时间与总时间
+type cpuReader interface {
+	read() (busy, total float64, err error)
+}
+
+// startCPUSampler 启动cpu使用率采样，采用指数滑动平均平滑结果
+func startCPUSampler() {
+	cpuOnce.Do(func() {
+		reader, err := newCPUReader()
+		if err != nil {
+			return
+		}
+		go func() {
+			prevBusy, prevTotal, err := reader.read()
+			if err != nil {
+				return
+			}
+			ticker := time.NewTicker(cpuPeriod)
+			defer ticker.Stop()
+			for range ticker.C {
+				busy, total, err := reader.read()
+				if err != nil {
+					continue
+				}
+				if total <= prevTotal {
+					continue
+				}
+				usage := math.Min(1, math.Max(0, (busy-prevBusy)/(total-prevTotal))) * 1000
+				prevBusy, prevTotal = busy, total
+				prev := float64(atomic.LoadInt64(&cpuUsage))
+				atomic.StoreInt64(&cpuUsage, int64(prev*cpuDecay+usage*(1-cpuDecay)))
+			}
+		}()
+	})
+}
+
+// loadCPUUsage 获取当前cpu使用率
+func loadCPUUsage() int64 {
+	return atomic.LoadInt64(&cpuUsage)
+}
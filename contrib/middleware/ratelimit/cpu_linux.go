@@ -0,0 +1,127 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package ratelimit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procStatPath     = "/proc/stat"
+	cgroupV2StatPath = "/sys/fs/cgroup/cpu.stat"
+	cgroupV2MaxPath  = "/sys/fs/cgroup/cpu.max"
+)
+
+func newCPUReader() (cpuReader, error) {
+	if _, err := os.Stat(cgroupV2StatPath); err == nil {
+		return &cgroupReader{start: time.Now(), cores: cgroupCores()}, nil
+	}
+	if _, err := os.Stat(procStatPath); err != nil {
+		return nil, err
+	}
+	return &procReader{}, nil
+}
+
+// procReader 从/proc/stat中读取整机cpu时间
+type procReader struct{}
+
+func (r *procReader) read() (busy, total float64, err error) {
+	f, err := os.Open(procStatPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var idle float64
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			total += v
+			// idle 与 iowait
+			if i == 3 || i == 4 {
+				idle += v
+			}
+		}
+		return total - idle, total, nil
+	}
+	return 0, 0, errors.New("cpu line not found in " + procStatPath)
+}
+
+// cgroupReader 从cgroup v2中读取容器cpu时间
+type cgroupReader struct {
+	start time.Time
+	cores float64
+}
+
+func (r *cgroupReader) read() (busy, total float64, err error) {
+	data, err := os.ReadFile(cgroupV2StatPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			busy, err = strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			return busy, float64(time.Since(r.start).Microseconds()) * r.cores, nil
+		}
+	}
+	return 0, 0, errors.New("usage_usec not found in " + cgroupV2StatPath)
+}
+
+// cgroupCores 根据cpu.max计算可用的cpu核数
+func cgroupCores() float64 {
+	cores := float64(runtime.NumCPU())
+	data, err := os.ReadFile(cgroupV2MaxPath)
+	if err != nil {
+		return cores
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return cores
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return cores
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || period <= 0 {
+		return cores
+	}
+	if limit := quota / period; limit < cores {
+		return limit
+	}
+	return cores
+}
@@ -0,0 +1,104 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/go-ceres/ceres/internal/matcher"
+	"time"
+)
+
+// Option 限流中间件参数
+type Option func(o *Options)
+
+// Options 限流中间件参数信息
+type Options struct {
+	Window       time.Duration   `json:"window"`       // 统计窗口时长，默认值：10s
+	Bucket       int             `json:"bucket"`       // 统计窗口桶数量，默认值：100
+	CPUThreshold int64           `json:"cpuThreshold"` // 触发限流的cpu使用率(0~1000)，小于等于0时不检测cpu，默认值：800
+	CoolDown     time.Duration   `json:"coolDown"`     // 丢弃请求后的冷却时间，默认值：1s
+	Priority     Priority        `json:"priority"`     // 未匹配到选择器时的默认优先级，默认值：PriorityNormal
+	priorities   matcher.Matcher // 按操作设置的优先级
+	cpuGetter    func() int64    // cpu使用率获取方法
+	limiter      Limiter         // 限流器
+}
+
+// DefaultOptions 默认参数
+func DefaultOptions() *Options {
+	return &Options{
+		Window:       10 * time.Second,
+		Bucket:       100,
+		CPUThreshold: 800,
+		CoolDown:     time.Second,
+		Priority:     PriorityNormal,
+		priorities:   matcher.New(),
+	}
+}
+
+// WithWindow 设置统计窗口时长
+func WithWindow(window time.Duration) Option {
+	return func(o *Options) {
+		o.Window = window
+	}
+}
+
+// WithBucket 设置统计窗口桶数量
+func WithBucket(bucket int) Option {
+	return func(o *Options) {
+		o.Bucket = bucket
+	}
+}
+
+// WithCPUThreshold 设置触发限流的cpu使用率，小于等于0时不检测cpu，始终按并发判断
+func WithCPUThreshold(threshold int64) Option {
+	return func(o *Options) {
+		o.CPUThreshold = threshold
+	}
+}
+
+// WithCoolDown 设置丢弃请求后的冷却时间
+func WithCoolDown(coolDown time.Duration) Option {
+	return func(o *Options) {
+		o.CoolDown = coolDown
+	}
+}
+
+// WithDefaultPriority 设置默认优先级
+func WithDefaultPriority(priority Priority) Option {
+	return func(o *Options) {
+		o.Priority = priority
+	}
+}
+
+// WithPriority 为匹配选择器的操作设置优先级，选择器规则与 AddServerMiddleware 一致
+func WithPriority(selector string, priority Priority) Option {
+	return func(o *Options) {
+		o.priorities.Add(selector, priorityMiddleware(priority))
+	}
+}
+
+// WithCPUGetter 自定义cpu使用率获取方法
+func WithCPUGetter(getter func() int64) Option {
+	return func(o *Options) {
+		o.cpuGetter = getter
+	}
+}
+
+// WithLimiter 自定义限流器
+func WithLimiter(limiter Limiter) Option {
+	return func(o *Options) {
+		o.limiter = limiter
+	}
+}
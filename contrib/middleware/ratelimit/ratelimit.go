@@ -0,0 +1,77 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+type priorityKey struct{}
+
+// NewPriorityContext 设置当前请求的优先级
+func NewPriorityContext(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext 获取当前请求的优先级
+func PriorityFromContext(ctx context.Context) (priority Priority, ok bool) {
+	priority, ok = ctx.Value(priorityKey{}).(Priority)
+	return
+}
+
+// priorityMiddleware 标记请求优先级的中间件，用于复用中间件选择器的匹配规则
+func priorityMiddleware(priority Priority) transport.Middleware {
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			return handler(NewPriorityContext(ctx, priority), req)
+		}
+	}
+}
+
+// Server 服务端自适应限流中间件
+func Server(opts ...Option) transport.Middleware {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	limiter := o.limiter
+	if limiter == nil {
+		limiter = newLimiter(o)
+	}
+	return func(handler transport.Handler) transport.Handler {
+		limit := func(ctx context.Context, req interface{}) (interface{}, error) {
+			priority, ok := PriorityFromContext(ctx)
+			if !ok {
+				priority = o.Priority
+			}
+			done, err := limiter.Allow(priority)
+			if err != nil {
+				return nil, err
+			}
+			defer done()
+			return handler(ctx, req)
+		}
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if md, ok := transport.MetadataFromServerContext(ctx); ok {
+				if ms := o.priorities.Match(md.Operation()); len(ms) > 0 {
+					return transport.MiddlewareChain(ms...)(limit)(ctx, req)
+				}
+			}
+			return limit(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	cpu := int64(0)
+	l := NewLimiter(
+		WithWindow(time.Second),
+		WithBucket(10),
+		WithCPUGetter(func() int64 { return cpu }),
+	).(*bbr)
+
+	// 低负载时不限流
+	dones := make([]DoneFunc, 0)
+	for i := 0; i < 100; i++ {
+		done, err := l.Allow(PriorityNormal)
+		if err != nil {
+			t.Fatalf("should not drop request when cpu is idle: %v", err)
+		}
+		dones = append(dones, done)
+	}
+	for _, done := range dones {
+		done()
+	}
+	for i := 0; i < 1000; i++ {
+		done, err := l.Allow(PriorityNormal)
+		if err != nil {
+			t.Fatalf("should not drop request when cpu is idle: %v", err)
+		}
+		done()
+	}
+
+	// 等待当前桶结束后参与统计，亚毫秒响应时最小响应时间按1ms计算，估算并发不为0
+	time.Sleep(110 * time.Millisecond)
+	if rt := l.minRT(); rt != 1 {
+		t.Fatalf("want min rt 1ms, got %d", rt)
+	}
+	maxInFlight := l.maxInFlight()
+	if maxInFlight < 1 {
+		t.Fatalf("want max in flight at least 1, got %d", maxInFlight)
+	}
+
+	// cpu过载时，超过估算并发的请求被丢弃，关键请求不受影响
+	cpu = 900
+	dropped := false
+	for i := 0; i < 100; i++ {
+		if _, err := l.Allow(PriorityNormal); err != nil {
+			if !errors.Is(err, ErrLimitExceed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if int64(i) < maxInFlight {
+				t.Fatalf("dropped after %d requests, want at least %d admitted", i, maxInFlight)
+			}
+			dropped = true
+			break
+		}
+	}
+	if !dropped {
+		t.Fatal("should drop request when overloaded")
+	}
+	if _, err := l.Allow(PriorityCritical); err != nil {
+		t.Fatalf("critical request should never be dropped: %v", err)
+	}
+}
+
+func TestLimiterWithoutCPUThreshold(t *testing.T) {
+	l := NewLimiter(WithWindow(time.Second), WithBucket(10), WithCPUThreshold(0)).(*bbr)
+	// 不检测cpu，按并发判断
+	dropped := false
+	for i := 0; i < 100; i++ {
+		if _, err := l.Allow(PriorityNormal); err != nil {
+			if !errors.Is(err, ErrLimitExceed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dropped = true
+			break
+		}
+	}
+	if !dropped {
+		t.Fatal("should drop request by in-flight count when cpu check is disabled")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	ceresgorm "github.com/go-ceres/ceres/pkg/common/store/gorm"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "ceres:tracing:span"
+
+var _ gorm.Plugin = (*gormPlugin)(nil)
+
+// gormPlugin gorm链路追踪插件
+type gormPlugin struct {
+	tracer *Tracer
+}
+
+// GormPlugin 创建gorm链路追踪插件，通过 store/gorm 的 WithPlugins 注册，
+// 查询需使用 db.WithContext(ctx) 传入请求上下文
+func GormPlugin(opts ...Option) gorm.Plugin {
+	return &gormPlugin{
+		tracer: NewTracer(trace.SpanKindClient, opts...),
+	}
+}
+
+func (p *gormPlugin) Name() string {
+	return "ceres:tracing"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("ceres:tracing:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("ceres:tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("ceres:tracing:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("ceres:tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("ceres:tracing:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("ceres:tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("ceres:tracing:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("ceres:tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("ceres:tracing:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("ceres:tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("ceres:tracing:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("ceres:tracing:after_raw", p.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before 开始span并替换语句上下文
+func (p *gormPlugin) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, span := p.tracer.tracer.Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperation(operation),
+			),
+		)
+		if traceID := TraceID(ctx); traceID != "" {
+			ctx = ceresgorm.NewTraceID(ctx, traceID)
+		}
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// after 记录语句与错误并结束span
+func (p *gormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	if table := db.Statement.Table; table != "" {
+		span.SetAttributes(semconv.DBSQLTable(table))
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(semconv.DBStatement(sql))
+	}
+	span.SetAttributes(DBRowsAffectedKey.Int64(db.Statement.RowsAffected))
+	err := db.Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	p.tracer.End(span, err)
+}
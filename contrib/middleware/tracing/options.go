@@ -0,0 +1,63 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultTracerName = "github.com/go-ceres/ceres"
+
+type Option func(o *Options)
+
+// Options 链路追踪配置
+type Options struct {
+	tracerName     string                        // 追踪器名称
+	tracerProvider trace.TracerProvider          // 追踪器提供者
+	propagator     propagation.TextMapPropagator // 上下文传播器
+}
+
+// DefaultOptions 默认配置，使用全局的追踪器提供者，传播W3C traceparent与baggage
+func DefaultOptions() *Options {
+	return &Options{
+		tracerName:     defaultTracerName,
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+}
+
+// WithTracerName 设置追踪器名称
+func WithTracerName(name string) Option {
+	return func(o *Options) {
+		o.tracerName = name
+	}
+}
+
+// WithTracerProvider 设置追踪器提供者
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.tracerProvider = provider
+	}
+}
+
+// WithPropagator 设置上下文传播器
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(o *Options) {
+		o.propagator = propagator
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/client/redis"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 创建redis链路追踪钩子，通过 client/redis 的 WithHooks 注册，
+// 命令需通过 client.WithContext(ctx) 执行才能关联到请求链路
+func RedisHook(opts ...Option) redis.Hook {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+	return func(ctx context.Context, cmd redis.Cmder, next func(cmd redis.Cmder) error) error {
+		_, span := tracer.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperation(cmd.Name()),
+			),
+		)
+		err := next(cmd)
+		if err == redis.Nil {
+			tracer.End(span, nil)
+		} else {
+			tracer.End(span, err)
+		}
+		return err
+	}
+}
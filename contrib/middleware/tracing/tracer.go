@@ -0,0 +1,111 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ErrorCodeKey 错误码属性
+	ErrorCodeKey = attribute.Key("error.code")
+	// ErrorReasonKey 错误原因属性
+	ErrorReasonKey = attribute.Key("error.reason")
+	// TransportKindKey 传输协议属性
+	TransportKindKey = attribute.Key("transport.kind")
+	// TransportEndpointKey 服务地址属性
+	TransportEndpointKey = attribute.Key("transport.endpoint")
+	// DBRowsAffectedKey 影响行数属性
+	DBRowsAffectedKey = attribute.Key("db.rows_affected")
+)
+
+// Tracer 链路追踪器
+type Tracer struct {
+	kind   trace.SpanKind
+	opts   *Options
+	tracer trace.Tracer
+}
+
+// NewTracer 创建追踪器
+func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Tracer{
+		kind:   kind,
+		opts:   o,
+		tracer: o.tracerProvider.Tracer(o.tracerName),
+	}
+}
+
+// Start 开始一个span，服务端从载体中提取上游上下文，客户端将当前上下文注入载体
+func (t *Tracer) Start(ctx context.Context, operation string, carrier propagation.TextMapCarrier, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t.kind == trace.SpanKindServer || t.kind == trace.SpanKindConsumer {
+		ctx = t.opts.propagator.Extract(ctx, carrier)
+	}
+	ctx, span := t.tracer.Start(ctx, operation, trace.WithSpanKind(t.kind), trace.WithAttributes(attrs...))
+	if t.kind == trace.SpanKindClient || t.kind == trace.SpanKindProducer {
+		t.opts.propagator.Inject(ctx, carrier)
+	}
+	return ctx, span
+}
+
+// End 结束span，记录错误码与错误原因
+func (t *Tracer) End(span trace.Span, err error) {
+	setError(span, err)
+	span.End()
+}
+
+// Inject 将上下文注入载体
+func (t *Tracer) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	t.opts.propagator.Inject(ctx, carrier)
+}
+
+// setError 记录错误信息
+func setError(span trace.Span, err error) {
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetAttributes(
+		ErrorCodeKey.Int64(int64(errors.Code(err))),
+		ErrorReasonKey.String(errors.Reason(err)),
+	)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID 获取上下文中的追踪ID
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID 获取上下文中的spanID
+func SpanID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
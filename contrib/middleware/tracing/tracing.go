@@ -0,0 +1,75 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/store/gorm"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Server 服务端链路追踪中间件，从请求头提取上游链路并将当前链路写入响应头
+func Server(opts ...Option) transport.Middleware {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md, ok := transport.MetadataFromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ctx, span := tracer.Start(ctx, md.Operation(), md.RequestHeader(), attributes(md)...)
+			if reply := md.ReplyHeader(); reply != nil {
+				tracer.Inject(ctx, reply)
+			}
+			// 同步追踪ID，使数据库日志可与请求关联
+			ctx = gorm.NewTraceID(ctx, TraceID(ctx))
+			reply, err := handler(ctx, req)
+			tracer.End(span, err)
+			return reply, err
+		}
+	}
+}
+
+// Client 客户端链路追踪中间件，将当前链路注入请求头
+func Client(opts ...Option) transport.Middleware {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md, ok := transport.MetadataFromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ctx, span := tracer.Start(ctx, md.Operation(), md.RequestHeader(), attributes(md)...)
+			reply, err := handler(ctx, req)
+			if p, ok := transport.FromPeerContext(ctx); ok && p.Node != nil {
+				span.SetAttributes(semconv.ServerAddress(p.Node.Address()))
+			}
+			tracer.End(span, err)
+			return reply, err
+		}
+	}
+}
+
+// attributes 请求的基础属性
+func attributes(md transport.Metadata) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		TransportKindKey.String(md.Kind().String()),
+		TransportEndpointKey.String(md.Endpoint()),
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	ceresgorm "github.com/go-ceres/ceres/pkg/common/store/gorm"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type mockHeader map[string]string
+
+func (h mockHeader) Get(key string) string { return h[key] }
+func (h mockHeader) Set(key, value string) { h[key] = value }
+func (h mockHeader) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type mockMetadata struct {
+	operation string
+	request   mockHeader
+	reply     mockHeader
+}
+
+func (m *mockMetadata) Kind() transport.Kind            { return "mock" }
+func (m *mockMetadata) Endpoint() string                { return "mock://127.0.0.1" }
+func (m *mockMetadata) Operation() string               { return m.operation }
+func (m *mockMetadata) RequestHeader() transport.Header { return m.request }
+func (m *mockMetadata) ReplyHeader() transport.Header   { return m.reply }
+
+func newMetadata(operation string) *mockMetadata {
+	return &mockMetadata{operation: operation, request: mockHeader{}, reply: mockHeader{}}
+}
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+func attr(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestClientServerPropagation(t *testing.T) {
+	provider, recorder := newProvider()
+	var serverTraceID, gormTraceID string
+	server := Server(WithTracerProvider(provider))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		serverTraceID = TraceID(ctx)
+		gormTraceID, _ = ceresgorm.FromTraceID(ctx)
+		return "ok", nil
+	})
+	clientMd := newMetadata("/helloworld.Greeter/SayHello")
+	client := Client(WithTracerProvider(provider))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 模拟网络传输，将客户端请求头传递给服务端
+		serverMd := newMetadata("/helloworld.Greeter/SayHello")
+		for _, key := range clientMd.request.Keys() {
+			serverMd.request.Set(key, clientMd.request.Get(key))
+		}
+		return server(transport.NewMetadataServerContext(context.Background(), serverMd), req)
+	})
+	if _, err := client(transport.NewMetadataClientContext(context.Background(), clientMd), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if clientMd.request.Get("traceparent") == "" {
+		t.Fatal("traceparent not injected")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+	serverSpan, clientSpan := spans[0], spans[1]
+	if serverSpan.SpanKind() != trace.SpanKindServer || clientSpan.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected span kinds %v %v", serverSpan.SpanKind(), clientSpan.SpanKind())
+	}
+	if serverSpan.Name() != "/helloworld.Greeter/SayHello" {
+		t.Fatalf("unexpected span name %s", serverSpan.Name())
+	}
+	if serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() {
+		t.Fatal("server span is not a child of client span")
+	}
+	if serverTraceID != clientSpan.SpanContext().TraceID().String() || gormTraceID != serverTraceID {
+		t.Fatalf("trace id mismatch %s %s", serverTraceID, gormTraceID)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	provider, recorder := newProvider()
+	server := Server(WithTracerProvider(provider))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user not found")
+	})
+	md := newMetadata("/user.User/Get")
+	if _, err := server(transport.NewMetadataServerContext(context.Background(), md), nil); err == nil {
+		t.Fatal("want error")
+	}
+	if md.reply.Get("traceparent") == "" {
+		t.Fatal("traceparent not written to reply header")
+	}
+	span := recorder.Ended()[0]
+	if code, ok := attr(span.Attributes(), ErrorCodeKey); !ok || code.AsInt64() != 404 {
+		t.Fatalf("unexpected error code %v", code)
+	}
+	if reason, ok := attr(span.Attributes(), ErrorReasonKey); !ok || reason.AsString() != "USER_NOT_FOUND" {
+		t.Fatalf("unexpected error reason %v", reason)
+	}
+}
+
+func TestGormPlugin(t *testing.T) {
+	provider, recorder := newProvider()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(GormPlugin(WithTracerProvider(provider))); err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	type User struct {
+		ID   uint
+		Name string
+	}
+	db.WithContext(ctx).Where("name = ?", "ceres").Find(&[]User{})
+	parent.End()
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "gorm.query" || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("unexpected span %s", span.Name())
+	}
+	if table, ok := attr(span.Attributes(), "db.sql.table"); !ok || table.AsString() != "users" {
+		t.Fatalf("unexpected table %v", table)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"github.com/hashicorp/consul/api"
+	"time"
+)
+
+const (
+	// HealthCheckTTL 由注册中心定时上报心跳
+	HealthCheckTTL = "ttl"
+	// HealthCheckEndpoint 由consul根据服务地址检查，grpc地址使用grpc健康检查，http地址使用http或tcp检查
+	HealthCheckEndpoint = "endpoint"
+	// HealthCheckNone 不注册健康检查
+	HealthCheckNone = "none"
+)
+
+type Option func(o *Options)
+
+// Options 配置信息
+type Options struct {
+	Address                        string        `json:"address"`                        // consul地址，默认值：127.0.0.1:8500
+	Scheme                         string        `json:"scheme"`                         // 协议，默认值：http
+	Token                          string        `json:"token"`                          // ACL令牌
+	Datacenter                     string        `json:"datacenter"`                     // 服务发现使用的数据中心，默认为agent所在的数据中心
+	Tags                           []string      `json:"tags"`                           // 注册服务时附加的标签
+	HealthCheck                    string        `json:"healthCheck"`                    // 健康检查方式：ttl、endpoint、none，默认值：ttl
+	HealthCheckInterval            time.Duration `json:"healthCheckInterval"`            // 健康检查间隔，ttl方式下为心跳间隔，默认值：10s
+	HealthCheckTimeout             time.Duration `json:"healthCheckTimeout"`             // endpoint方式下的检查超时时间，默认值：5s
+	HealthCheckPath                string        `json:"healthCheckPath"`                // http地址的检查路径，为空时使用tcp检查
+	DeregisterCriticalServiceAfter time.Duration `json:"deregisterCriticalServiceAfter"` // 健康检查失败多久后自动注销服务，默认值：10m
+	HealthyOnly                    bool          `json:"healthyOnly"`                    // 服务发现是否只返回健康的实例，默认值：true
+	WaitTime                       time.Duration `json:"waitTime"`                       // 阻塞查询的最长等待时间，默认值：55s
+	client                         *api.Client
+	logger                         *logger.Logger
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Address:                        "127.0.0.1:8500",
+		Scheme:                         "http",
+		HealthCheck:                    HealthCheckTTL,
+		HealthCheckInterval:            10 * time.Second,
+		HealthCheckTimeout:             5 * time.Second,
+		DeregisterCriticalServiceAfter: 10 * time.Minute,
+		HealthyOnly:                    true,
+		WaitTime:                       55 * time.Second,
+		logger:                         logger.With(logger.FieldMod("registry.consul")),
+	}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.consul"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithAddress consul地址
+func WithAddress(address string) Option {
+	return func(o *Options) {
+		o.Address = address
+	}
+}
+
+// WithScheme 设置协议
+func WithScheme(scheme string) Option {
+	return func(o *Options) {
+		o.Scheme = scheme
+	}
+}
+
+// WithToken 设置ACL令牌
+func WithToken(token string) Option {
+	return func(o *Options) {
+		o.Token = token
+	}
+}
+
+// WithDatacenter 设置服务发现使用的数据中心
+func WithDatacenter(datacenter string) Option {
+	return func(o *Options) {
+		o.Datacenter = datacenter
+	}
+}
+
+// WithTags 设置注册服务时附加的标签
+func WithTags(tags ...string) Option {
+	return func(o *Options) {
+		o.Tags = tags
+	}
+}
+
+// WithHealthCheck 设置健康检查方式
+func WithHealthCheck(healthCheck string) Option {
+	return func(o *Options) {
+		o.HealthCheck = healthCheck
+	}
+}
+
+// WithHealthCheckInterval 设置健康检查间隔
+func WithHealthCheckInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.HealthCheckInterval = interval
+	}
+}
+
+// WithHealthCheckTimeout 设置健康检查超时时间
+func WithHealthCheckTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.HealthCheckTimeout = timeout
+	}
+}
+
+// WithHealthCheckPath 设置http地址的检查路径
+func WithHealthCheckPath(path string) Option {
+	return func(o *Options) {
+		o.HealthCheckPath = path
+	}
+}
+
+// WithDeregisterCriticalServiceAfter 设置健康检查失败多久后自动注销服务
+func WithDeregisterCriticalServiceAfter(d time.Duration) Option {
+	return func(o *Options) {
+		o.DeregisterCriticalServiceAfter = d
+	}
+}
+
+// WithHealthyOnly 设置服务发现是否只返回健康的实例
+func WithHealthyOnly(healthyOnly bool) Option {
+	return func(o *Options) {
+		o.HealthyOnly = healthyOnly
+	}
+}
+
+// WithWaitTime 设置阻塞查询的最长等待时间
+func WithWaitTime(waitTime time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTime = waitTime
+	}
+}
+
+// WithClient 使用已有的consul客户端
+func WithClient(client *api.Client) Option {
+	return func(o *Options) {
+		o.client = client
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(log *logger.Logger) Option {
+	return func(o *Options) {
+		o.logger = log
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建注册中心
+func (o *Options) Build() *Registry {
+	return NewWithOptions(o)
+}
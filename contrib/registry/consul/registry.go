@@ -0,0 +1,277 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/hashicorp/consul/api"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ transport.Registry = (*Registry)(nil)
+	_ transport.Discover = (*Registry)(nil)
+)
+
+const metadataVersion = "version"
+
+// Registry consul注册中心实现
+type Registry struct {
+	options *Options
+	client  *api.Client
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // 服务ID -> 心跳协程的取消函数
+}
+
+// New 创建consul注册中心
+func New(opts ...Option) *Registry {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建consul注册中心
+func NewWithOptions(options *Options) *Registry {
+	client := options.client
+	if client == nil {
+		var err error
+		client, err = api.NewClient(&api.Config{
+			Address: options.Address,
+			Scheme:  options.Scheme,
+			Token:   options.Token,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return &Registry{
+		options: options,
+		client:  client,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Register 注册服务，服务的每个地址以协议为key写入 TaggedAddresses
+func (r *Registry) Register(ctx context.Context, service *transport.ServiceInfo) error {
+	if service.Name == "" {
+		return errors.New("ServiceInfo.name is empty")
+	}
+	registration, err := r.registration(service)
+	if err != nil {
+		return err
+	}
+	if err = r.client.Agent().ServiceRegisterOpts(registration, api.ServiceRegisterOpts{ReplaceExistingChecks: true}.WithContext(ctx)); err != nil {
+		return err
+	}
+	if r.options.HealthCheck != HealthCheckTTL {
+		return nil
+	}
+	checkID := "service:" + registration.ID
+	if err = r.client.Agent().UpdateTTLOpts(checkID, "pass", api.HealthPassing, new(api.QueryOptions).WithContext(ctx)); err != nil {
+		r.options.logger.Warnf("[registry.consul] failed to update ttl of %s: %v", registration.ID, err)
+	}
+	hctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	if c, ok := r.cancels[registration.ID]; ok {
+		c()
+	}
+	r.cancels[registration.ID] = cancel
+	r.mu.Unlock()
+	go r.heartbeat(hctx, checkID, registration)
+	return nil
+}
+
+// Deregister 注销服务
+func (r *Registry) Deregister(ctx context.Context, service *transport.ServiceInfo) error {
+	id := serviceID(service)
+	r.mu.Lock()
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		delete(r.cancels, id)
+	}
+	r.mu.Unlock()
+	return r.client.Agent().ServiceDeregisterOpts(id, new(api.QueryOptions).WithContext(ctx))
+}
+
+// GetService 获取服务的全部实例
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	services, _, err := r.service(ctx, serviceName, 0)
+	return services, err
+}
+
+// Watch 使用阻塞查询监听服务
+func (r *Registry) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	return newWatcher(ctx, r, serviceName), nil
+}
+
+// service 查询服务实例，index 大于0时为阻塞查询，直到服务变化或等待超时
+func (r *Registry) service(ctx context.Context, serviceName string, index uint64) ([]*transport.ServiceInfo, uint64, error) {
+	opts := &api.QueryOptions{
+		Datacenter: r.options.Datacenter,
+		WaitIndex:  index,
+		WaitTime:   r.options.WaitTime,
+	}
+	entries, meta, err := r.client.Health().Service(serviceName, "", r.options.HealthyOnly, opts.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]*transport.ServiceInfo, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, toServiceInfo(entry.Service))
+	}
+	return items, meta.LastIndex, nil
+}
+
+// heartbeat ttl健康检查的心跳，失败时重新注册服务
+func (r *Registry) heartbeat(ctx context.Context, checkID string, registration *api.AgentServiceRegistration) {
+	ticker := time.NewTicker(r.options.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := r.client.Agent().UpdateTTLOpts(checkID, "pass", api.HealthPassing, new(api.QueryOptions).WithContext(ctx))
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+		r.options.logger.Warnf("[registry.consul] failed to update ttl of %s: %v", registration.ID, err)
+		// 服务可能已被consul注销，例如agent重启，重新注册
+		if err = r.client.Agent().ServiceRegisterOpts(registration, api.ServiceRegisterOpts{ReplaceExistingChecks: true}.WithContext(ctx)); err != nil {
+			r.options.logger.Errorf("[registry.consul] failed to re-register %s: %v", registration.ID, err)
+		}
+	}
+}
+
+// registration 服务信息转换为consul注册信息
+func (r *Registry) registration(service *transport.ServiceInfo) (*api.AgentServiceRegistration, error) {
+	registration := &api.AgentServiceRegistration{
+		ID:              serviceID(service),
+		Name:            service.Name,
+		Tags:            append([]string(nil), r.options.Tags...),
+		Meta:            make(map[string]string, len(service.Metadata)+1),
+		TaggedAddresses: make(map[string]api.ServiceAddress, len(service.Endpoints)),
+	}
+	for k, v := range service.Metadata {
+		registration.Meta[k] = v
+	}
+	registration.Meta[metadataVersion] = service.Version
+	var checks api.AgentServiceChecks
+	for _, endpoint := range service.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		host, portStr, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, err
+		}
+		if registration.Address == "" {
+			registration.Address, registration.Port = host, port
+		}
+		registration.TaggedAddresses[u.Scheme] = api.ServiceAddress{Address: host, Port: port}
+		if r.options.HealthCheck == HealthCheckEndpoint {
+			checks = append(checks, r.endpointCheck(u))
+		}
+	}
+	switch r.options.HealthCheck {
+	case HealthCheckTTL:
+		registration.Checks = api.AgentServiceChecks{{
+			CheckID:                        "service:" + registration.ID,
+			TTL:                            (r.options.HealthCheckInterval * 2).String(),
+			DeregisterCriticalServiceAfter: r.options.DeregisterCriticalServiceAfter.String(),
+		}}
+	case HealthCheckEndpoint:
+		registration.Checks = checks
+	}
+	return registration, nil
+}
+
+// endpointCheck 根据服务地址生成健康检查，grpc地址使用grpc健康检查，http地址使用http或tcp检查
+func (r *Registry) endpointCheck(u *url.URL) *api.AgentServiceCheck {
+	check := &api.AgentServiceCheck{
+		Name:                           u.Scheme + " " + u.Host,
+		Interval:                       r.options.HealthCheckInterval.String(),
+		Timeout:                        r.options.HealthCheckTimeout.String(),
+		DeregisterCriticalServiceAfter: r.options.DeregisterCriticalServiceAfter.String(),
+	}
+	switch {
+	case u.Scheme == "grpc" || u.Scheme == "grpcs":
+		check.GRPC = u.Host
+		check.GRPCUseTLS = u.Scheme == "grpcs" || u.Query().Get("isSecure") == "true"
+	case (u.Scheme == "http" || u.Scheme == "https") && r.options.HealthCheckPath != "":
+		check.HTTP = u.Scheme + "://" + u.Host + "/" + strings.TrimPrefix(r.options.HealthCheckPath, "/")
+	default:
+		check.TCP = u.Host
+	}
+	return check
+}
+
+// toServiceInfo consul服务信息转换为服务信息，TaggedAddresses 还原为服务地址，k=v 形式的标签写入元数据
+func toServiceInfo(service *api.AgentService) *transport.ServiceInfo {
+	info := &transport.ServiceInfo{
+		ID:       service.ID,
+		Name:     service.Service,
+		Version:  service.Meta[metadataVersion],
+		Metadata: make(map[string]string, len(service.Meta)),
+	}
+	for _, tag := range service.Tags {
+		if k, v, ok := strings.Cut(tag, "="); ok && k != "" {
+			info.Metadata[k] = v
+		}
+	}
+	for k, v := range service.Meta {
+		info.Metadata[k] = v
+	}
+	schemes := make([]string, 0, len(service.TaggedAddresses))
+	for scheme := range service.TaggedAddresses {
+		// 跳过consul内置的 lan、wan 等地址
+		if strings.HasPrefix(scheme, "lan") || strings.HasPrefix(scheme, "wan") {
+			continue
+		}
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	for _, scheme := range schemes {
+		addr := service.TaggedAddresses[scheme]
+		info.Endpoints = append(info.Endpoints, fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(addr.Address, strconv.Itoa(addr.Port))))
+	}
+	return info
+}
+
+// serviceID consul服务ID，未设置ID时使用服务名与地址生成
+func serviceID(service *transport.ServiceInfo) string {
+	if service.ID != "" {
+		return service.ID
+	}
+	return service.Name + "-" + strings.Join(service.Endpoints, ",")
+}
@@ -0,0 +1,281 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/hashicorp/consul/api"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul 模拟consul agent的http接口
+type fakeConsul struct {
+	mu       sync.Mutex
+	index    uint64
+	changed  chan struct{}
+	services map[string]*api.AgentService
+	checks   map[string]string // 检查ID -> 状态
+	updates  int
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, *api.Client) {
+	f := &fakeConsul{
+		index:    1,
+		changed:  make(chan struct{}),
+		services: make(map[string]*api.AgentService),
+		checks:   make(map[string]string),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(srv.URL, "http://")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+// notify 数据变化，唤醒阻塞查询，需持有锁
+func (f *fakeConsul) notify() {
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/agent/service/register":
+		var reg api.AgentServiceRegistration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.services[reg.ID] = &api.AgentService{
+			ID:              reg.ID,
+			Service:         reg.Name,
+			Tags:            reg.Tags,
+			Meta:            reg.Meta,
+			Address:         reg.Address,
+			Port:            reg.Port,
+			TaggedAddresses: reg.TaggedAddresses,
+		}
+		for _, check := range reg.Checks {
+			if check.CheckID != "" {
+				f.checks[check.CheckID] = api.HealthCritical
+			}
+		}
+		f.notify()
+		f.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+		f.mu.Lock()
+		delete(f.services, strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/"))
+		f.notify()
+		f.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/update/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/agent/check/update/")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.checks[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.checks[id] = api.HealthPassing
+		f.updates++
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		f.health(w, r, strings.TrimPrefix(r.URL.Path, "/v1/health/service/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeConsul) health(w http.ResponseWriter, r *http.Request, name string) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	f.mu.Lock()
+	for index != 0 && f.index <= index {
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			index = 0
+		case <-r.Context().Done():
+			return
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+	_, passing := r.URL.Query()["passing"]
+	entries := make([]*api.ServiceEntry, 0)
+	for _, service := range f.services {
+		if service.Service != name {
+			continue
+		}
+		if status, ok := f.checks["service:"+service.ID]; passing && ok && status != api.HealthPassing {
+			continue
+		}
+		entries = append(entries, &api.ServiceEntry{Service: service})
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func next(t *testing.T, w transport.Watcher) []*transport.ServiceInfo {
+	type result struct {
+		services []*transport.ServiceInfo
+		err      error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		services, err := w.Next()
+		ch <- result{services, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		return r.services
+	case <-time.After(3 * time.Second):
+		t.Fatal("watcher next timeout")
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	f, client := newFakeConsul(t)
+	r := New(WithClient(client), WithTags("env=test"), WithHealthCheckInterval(50*time.Millisecond))
+	ctx := context.Background()
+	ins := &transport.ServiceInfo{
+		ID:        "greeter-1",
+		Name:      "greeter",
+		Version:   "v1.0.0",
+		Metadata:  map[string]string{"zone": "a"},
+		Endpoints: []string{"grpc://127.0.0.1:9000", "http://127.0.0.1:8000"},
+	}
+	if err := r.Register(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Deregister(ctx, ins)
+	services, err := r.GetService(ctx, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("want 1 instance, got %d", len(services))
+	}
+	got := services[0]
+	if got.ID != ins.ID || got.Version != ins.Version || got.Metadata["zone"] != "a" || got.Metadata["env"] != "test" {
+		t.Fatalf("unexpected instance %+v", got)
+	}
+	if len(got.Endpoints) != 2 || got.Endpoints[0] != ins.Endpoints[0] || got.Endpoints[1] != ins.Endpoints[1] {
+		t.Fatalf("unexpected endpoints %v", got.Endpoints)
+	}
+
+	// ttl心跳
+	time.Sleep(200 * time.Millisecond)
+	f.mu.Lock()
+	updates := f.updates
+	f.mu.Unlock()
+	if updates < 2 {
+		t.Fatalf("want ttl heartbeats, got %d", updates)
+	}
+
+	// agent丢失服务后心跳失败时重新注册
+	f.mu.Lock()
+	delete(f.services, ins.ID)
+	delete(f.checks, "service:"+ins.ID)
+	f.notify()
+	f.mu.Unlock()
+	time.Sleep(200 * time.Millisecond)
+	if services, _ = r.GetService(ctx, "greeter"); len(services) != 1 {
+		t.Fatal("instance must be re-registered")
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	_, client := newFakeConsul(t)
+	r := New(WithClient(client), WithHealthCheck(HealthCheckNone), WithWaitTime(100*time.Millisecond))
+	ctx := context.Background()
+	w, err := r.Watch(ctx, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 0 {
+		t.Fatalf("want empty services, got %v", services)
+	}
+	ins := &transport.ServiceInfo{ID: "greeter-1", Name: "greeter", Endpoints: []string{"grpc://127.0.0.1:9000"}}
+	go func() {
+		// 晚于等待时间注册，阻塞查询超时后需要继续等待
+		time.Sleep(300 * time.Millisecond)
+		_ = r.Register(ctx, ins)
+	}()
+	if services := next(t, w); len(services) != 1 || services[0].ID != ins.ID {
+		t.Fatalf("want registered instance, got %v", services)
+	}
+	if err := r.Deregister(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 0 {
+		t.Fatalf("want empty services, got %v", services)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	_ = w.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must unblock next")
+	}
+}
+
+func TestEndpointCheck(t *testing.T) {
+	r := New(WithClient(&api.Client{}), WithHealthCheck(HealthCheckEndpoint), WithHealthCheckPath("/healthz"))
+	reg, err := r.registration(&transport.ServiceInfo{
+		Name:      "greeter",
+		Endpoints: []string{"grpc://127.0.0.1:9000", "http://127.0.0.1:8000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reg.Checks) != 2 {
+		t.Fatalf("want 2 checks, got %d", len(reg.Checks))
+	}
+	if reg.Checks[0].GRPC != "127.0.0.1:9000" {
+		t.Fatalf("want grpc check, got %+v", reg.Checks[0])
+	}
+	if reg.Checks[1].HTTP != "http://127.0.0.1:8000/healthz" {
+		t.Fatalf("want http check, got %+v", reg.Checks[1])
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	registry    *Registry
+	serviceName string
+	index       uint64
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+func newWatcher(ctx context.Context, r *Registry, serviceName string) *watcher {
+	w := &watcher{
+		registry:    r,
+		serviceName: serviceName,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// Next 首次调用返回当前实例列表，之后使用阻塞查询等待服务变化
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	for {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
+		first := w.index == 0
+		services, index, err := w.registry.service(w.ctx, w.serviceName, w.index)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
+		}
+		if index < w.index {
+			// 索引回退时需要重新开始
+			w.index = 0
+			continue
+		}
+		if first || index != w.index {
+			w.index = index
+			return services, nil
+		}
+		// 等待超时，服务没有变化
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"time"
+)
+
+type Option func(o *Options)
+
+// Options 配置信息
+type Options struct {
+	Endpoints   []string      `json:"endpoints"`   // etcd地址
+	Username    string        `json:"username"`    // 用户名
+	Password    string        `json:"password"`    // 密码
+	DialTimeout time.Duration `json:"dialTimeout"` // 连接超时时间，默认值：3s
+	Namespace   string        `json:"namespace"`   // 服务注册的key前缀，默认值：/microservices
+	TTL         time.Duration `json:"ttl"`         // 租约时间，默认值：15s
+	MaxRetry    int           `json:"maxRetry"`    // 租约丢失后重新注册的最大重试次数，小于等于0时一直重试
+	client      *clientv3.Client
+	logger      *logger.Logger
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Endpoints:   []string{"127.0.0.1:2379"},
+		DialTimeout: 3 * time.Second,
+		Namespace:   "/microservices",
+		TTL:         15 * time.Second,
+		logger:      logger.With(logger.FieldMod("registry.etcd")),
+	}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.etcd"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithEndpoints etcd地址
+func WithEndpoints(endpoints ...string) Option {
+	return func(o *Options) {
+		o.Endpoints = endpoints
+	}
+}
+
+// WithAuth 设置认证信息
+func WithAuth(username, password string) Option {
+	return func(o *Options) {
+		o.Username = username
+		o.Password = password
+	}
+}
+
+// WithDialTimeout 设置连接超时时间
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.DialTimeout = timeout
+	}
+}
+
+// WithNamespace 设置服务注册的key前缀
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.Namespace = namespace
+	}
+}
+
+// WithTTL 设置租约时间
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithMaxRetry 设置重新注册的最大重试次数
+func WithMaxRetry(maxRetry int) Option {
+	return func(o *Options) {
+		o.MaxRetry = maxRetry
+	}
+}
+
+// WithClient 使用已有的etcd客户端，如 contrib/client/etcd 创建的客户端
+func WithClient(client *clientv3.Client) Option {
+	return func(o *Options) {
+		o.client = client
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(log *logger.Logger) Option {
+	return func(o *Options) {
+		o.logger = log
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建注册中心
+func (o *Options) Build() *Registry {
+	return NewWithOptions(o)
+}
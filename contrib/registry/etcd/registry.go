@@ -0,0 +1,238 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ transport.Registry = (*Registry)(nil)
+	_ transport.Discover = (*Registry)(nil)
+)
+
+// Registry etcd注册中心实现
+type Registry struct {
+	options *Options
+	client  *clientv3.Client
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // 服务key -> 续约协程的取消函数
+}
+
+// New 创建etcd注册中心
+func New(opts ...Option) *Registry {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建etcd注册中心
+func NewWithOptions(options *Options) *Registry {
+	client := options.client
+	if client == nil {
+		var err error
+		client, err = clientv3.New(clientv3.Config{
+			Endpoints:   options.Endpoints,
+			Username:    options.Username,
+			Password:    options.Password,
+			DialTimeout: options.DialTimeout,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return &Registry{
+		options: options,
+		client:  client,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Register 注册服务，使用租约保存服务信息并持续续约，租约丢失后自动重新注册
+func (r *Registry) Register(ctx context.Context, service *transport.ServiceInfo) error {
+	if service.Name == "" {
+		return errors.New("ServiceInfo.name is empty")
+	}
+	key := r.serviceKey(service)
+	value, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+	leaseID, err := r.register(ctx, key, string(value))
+	if err != nil {
+		return err
+	}
+	hctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	if c, ok := r.cancels[key]; ok {
+		c()
+	}
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+	go r.heartbeat(hctx, leaseID, key, string(value))
+	return nil
+}
+
+// Deregister 注销服务
+func (r *Registry) Deregister(ctx context.Context, service *transport.ServiceInfo) error {
+	key := r.serviceKey(service)
+	r.mu.Lock()
+	if cancel, ok := r.cancels[key]; ok {
+		cancel()
+		delete(r.cancels, key)
+	}
+	r.mu.Unlock()
+	_, err := r.client.Delete(ctx, key)
+	return err
+}
+
+// GetService 获取服务的全部实例
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	resp, err := r.client.Get(ctx, r.servicePrefix(serviceName), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*transport.ServiceInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		info, err := unmarshal(kv.Value)
+		if err != nil {
+			r.options.logger.Errorf("[registry.etcd] failed to unmarshal service %s: %v", kv.Key, err)
+			continue
+		}
+		if info.Name != serviceName {
+			continue
+		}
+		items = append(items, info)
+	}
+	return items, nil
+}
+
+// Watch 监听服务
+func (r *Registry) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	return newWatcher(ctx, r, serviceName), nil
+}
+
+// register 创建租约并写入服务信息
+func (r *Registry) register(ctx context.Context, key, value string) (clientv3.LeaseID, error) {
+	grant, err := r.client.Grant(ctx, int64(r.options.TTL.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	if _, err = r.client.Put(ctx, key, value, clientv3.WithLease(grant.ID)); err != nil {
+		return 0, err
+	}
+	return grant.ID, nil
+}
+
+// heartbeat 续约，租约丢失后按退避时间重新注册
+func (r *Registry) heartbeat(ctx context.Context, leaseID clientv3.LeaseID, key, value string) {
+	curLeaseID := leaseID
+	kac, err := r.client.KeepAlive(ctx, leaseID)
+	if err != nil {
+		curLeaseID = 0
+	}
+	for {
+		if curLeaseID == 0 {
+			// 租约丢失，重新注册
+			retry := 0
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				if r.options.MaxRetry > 0 && retry >= r.options.MaxRetry {
+					r.options.logger.Errorf("[registry.etcd] failed to re-register %s after %d retries", key, retry)
+					return
+				}
+				id, err := r.register(ctx, key, value)
+				if err == nil {
+					if kac, err = r.client.KeepAlive(ctx, id); err == nil {
+						curLeaseID = id
+						r.options.logger.Infof("[registry.etcd] re-registered %s", key)
+						break
+					}
+				}
+				r.options.logger.Warnf("[registry.etcd] failed to re-register %s: %v", key, err)
+				retry++
+				select {
+				case <-time.After(backoff(retry)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		select {
+		case _, ok := <-kac:
+			if !ok {
+				if ctx.Err() != nil {
+					// 注销时撤销租约
+					revokeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+					_, _ = r.client.Revoke(revokeCtx, curLeaseID)
+					cancel()
+					return
+				}
+				r.options.logger.Warnf("[registry.etcd] lease of %s lost", key)
+				curLeaseID = 0
+			}
+		case <-ctx.Done():
+			revokeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			_, _ = r.client.Revoke(revokeCtx, curLeaseID)
+			cancel()
+			return
+		}
+	}
+}
+
+// serviceKey 服务实例的key：{namespace}/{name}/{id}
+func (r *Registry) serviceKey(service *transport.ServiceInfo) string {
+	id := service.ID
+	if id == "" {
+		id = strings.Join(service.Endpoints, ",")
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(r.options.Namespace, "/"), service.Name, id)
+}
+
+// servicePrefix 服务的key前缀：{namespace}/{name}/
+func (r *Registry) servicePrefix(serviceName string) string {
+	return fmt.Sprintf("%s/%s/", strings.TrimSuffix(r.options.Namespace, "/"), serviceName)
+}
+
+func unmarshal(data []byte) (*transport.ServiceInfo, error) {
+	info := new(transport.ServiceInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// backoff 重新注册的退避时间，指数增长，最长1分钟
+func backoff(retry int) time.Duration {
+	d := time.Second << uint(retry-1)
+	if d <= 0 || d > time.Minute {
+		d = time.Minute
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,145 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func freePort(t *testing.T) int {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// startEtcd 启动内嵌的etcd服务
+func startEtcd(t *testing.T) *clientv3.Client {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", freePort(t)))
+	peerURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", freePort(t)))
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{*clientURL}, []url.URL{*clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{*peerURL}, []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd server start timeout")
+	}
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{clientURL.String()}, DialTimeout: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func waitFor(t *testing.T, msg string, fn func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if fn() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
+func TestRegistry(t *testing.T) {
+	client := startEtcd(t)
+	r := New(WithClient(client), WithNamespace("/ceres-test"), WithTTL(2*time.Second))
+	ctx := context.Background()
+
+	w, err := r.Watch(ctx, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if services, err := w.Next(); err != nil || len(services) != 0 {
+		t.Fatalf("want empty services, got %v %v", services, err)
+	}
+
+	ins := &transport.ServiceInfo{
+		ID:        "greeter-1",
+		Name:      "greeter",
+		Version:   "v1.0.0",
+		Endpoints: []string{"grpc://127.0.0.1:9000"},
+		Metadata:  map[string]string{"zone": "a"},
+	}
+	if err := r.Register(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	services, err := w.Next()
+	if err != nil || len(services) != 1 || services[0].ID != "greeter-1" || services[0].Metadata["zone"] != "a" {
+		t.Fatalf("want registered instance, got %v %v", services, err)
+	}
+	// 其他服务的实例不受前缀影响
+	_ = r.Register(ctx, &transport.ServiceInfo{ID: "1", Name: "greeter-admin"})
+	if services, _ := r.GetService(ctx, "greeter"); len(services) != 1 {
+		t.Fatalf("want one instance, got %v", services)
+	}
+
+	// 超过租约时间后依然存在，说明续约生效
+	time.Sleep(3 * time.Second)
+	if services, _ := r.GetService(ctx, "greeter"); len(services) != 1 {
+		t.Fatal("lease must be kept alive")
+	}
+
+	// 模拟租约丢失，自动重新注册
+	resp, err := client.Get(ctx, r.serviceKey(ins))
+	if err != nil || len(resp.Kvs) != 1 {
+		t.Fatalf("service key not found: %v", err)
+	}
+	if _, err := client.Revoke(ctx, clientv3.LeaseID(resp.Kvs[0].Lease)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "service must be re-registered after lease loss", func() bool {
+		services, _ := r.GetService(ctx, "greeter")
+		return len(services) == 1
+	})
+
+	if err := r.Deregister(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "watcher must observe deregistration", func() bool {
+		services, err := w.Next()
+		return err == nil && len(services) == 0
+	})
+
+	_ = w.Stop()
+	if _, err := w.Next(); err != context.Canceled {
+		t.Fatalf("want context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	registry    *Registry
+	serviceName string
+	prefix      string
+	first       bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+	watchChan   clientv3.WatchChan
+}
+
+func newWatcher(ctx context.Context, r *Registry, serviceName string) *watcher {
+	w := &watcher{
+		registry:    r,
+		serviceName: serviceName,
+		prefix:      r.servicePrefix(serviceName),
+		first:       true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.watch()
+	return w
+}
+
+func (w *watcher) watch() {
+	w.watchChan = w.registry.client.Watch(clientv3.WithRequireLeader(w.ctx), w.prefix, clientv3.WithPrefix(), clientv3.WithRev(0), clientv3.WithKeysOnly())
+}
+
+// Next 首次调用返回当前实例列表，之后在前缀下的key变化时返回最新的实例列表
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	if w.first {
+		w.first = false
+		return w.registry.GetService(w.ctx, w.serviceName)
+	}
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case resp, ok := <-w.watchChan:
+		if !ok || resp.Err() != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			// 监听中断，例如失去leader或被压缩，重新建立监听后返回全量实例
+			w.watch()
+		}
+		return w.registry.GetService(w.ctx, w.serviceName)
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"k8s.io/client-go/kubernetes"
+	"os"
+	"strings"
+)
+
+// namespaceFile 集群内运行时当前命名空间所在的文件
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type Option func(o *Options)
+
+// Options 配置信息
+type Options struct {
+	Namespace        string `json:"namespace"`        // 默认命名空间，发现目标未指定时使用，默认为当前pod所在的命名空间
+	KubeConfig       string `json:"kubeConfig"`       // kubeconfig文件路径，为空时使用集群内配置
+	Scheme           string `json:"scheme"`           // 服务地址的协议，为空时根据端口的appProtocol或名称推断
+	VersionLabel     string `json:"versionLabel"`     // pod中服务版本的标签，默认值：app.kubernetes.io/version
+	AnnotationPrefix string `json:"annotationPrefix"` // 写入元数据的pod注解前缀，写入时去掉前缀，默认值：ceres.io/
+	IncludeNotReady  bool   `json:"includeNotReady"`  // 是否包含未就绪的节点
+	clientset        kubernetes.Interface
+	logger           *logger.Logger
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	namespace := "default"
+	if data, err := os.ReadFile(namespaceFile); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		namespace = strings.TrimSpace(string(data))
+	}
+	return &Options{
+		Namespace:        namespace,
+		VersionLabel:     "app.kubernetes.io/version",
+		AnnotationPrefix: "ceres.io/",
+		logger:           logger.With(logger.FieldMod("registry.kubernetes")),
+	}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.kubernetes"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithNamespace 设置默认命名空间
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.Namespace = namespace
+	}
+}
+
+// WithKubeConfig 设置kubeconfig文件路径
+func WithKubeConfig(path string) Option {
+	return func(o *Options) {
+		o.KubeConfig = path
+	}
+}
+
+// WithScheme 设置服务地址的协议
+func WithScheme(scheme string) Option {
+	return func(o *Options) {
+		o.Scheme = scheme
+	}
+}
+
+// WithVersionLabel 设置服务版本的标签
+func WithVersionLabel(label string) Option {
+	return func(o *Options) {
+		o.VersionLabel = label
+	}
+}
+
+// WithAnnotationPrefix 设置写入元数据的注解前缀
+func WithAnnotationPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.AnnotationPrefix = prefix
+	}
+}
+
+// WithIncludeNotReady 设置是否包含未就绪的节点
+func WithIncludeNotReady(include bool) Option {
+	return func(o *Options) {
+		o.IncludeNotReady = include
+	}
+}
+
+// WithClientset 使用已有的kubernetes客户端
+func WithClientset(clientset kubernetes.Interface) Option {
+	return func(o *Options) {
+		o.clientset = clientset
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(log *logger.Logger) Option {
+	return func(o *Options) {
+		o.logger = log
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建注册中心
+func (o *Options) Build() *Registry {
+	return NewWithOptions(o)
+}
@@ -0,0 +1,329 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/go-ceres/ceres/pkg/transport/filter"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	_ transport.Registry = (*Registry)(nil)
+	_ transport.Discover = (*Registry)(nil)
+)
+
+// Registry 基于kubernetes EndpointSlice的服务发现，
+// 集群内服务由kubernetes管理生命周期，注册与注销不做任何处理，
+// 需要对发现目标所在命名空间的 endpointslices(discovery.k8s.io)、pods 具有 get、list、watch 权限，对 services 具有 get 权限
+type Registry struct {
+	options   *Options
+	clientset kubernetes.Interface
+	mu        sync.Mutex
+	pods      map[string]*podInformer // 按命名空间共享的pod缓存
+}
+
+// podInformer 命名空间内共享的pod缓存，最后一个监听者停止时关闭
+type podInformer struct {
+	informer cache.SharedIndexInformer
+	cancel   context.CancelFunc
+	refs     int
+}
+
+// New 创建kubernetes注册中心
+func New(opts ...Option) *Registry {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建kubernetes注册中心
+func NewWithOptions(options *Options) *Registry {
+	clientset := options.clientset
+	if clientset == nil {
+		var (
+			conf *rest.Config
+			err  error
+		)
+		if options.KubeConfig != "" {
+			conf, err = clientcmd.BuildConfigFromFlags("", options.KubeConfig)
+		} else {
+			conf, err = rest.InClusterConfig()
+		}
+		if err != nil {
+			panic(err)
+		}
+		if clientset, err = kubernetes.NewForConfig(conf); err != nil {
+			panic(err)
+		}
+	}
+	return &Registry{
+		options:   options,
+		clientset: clientset,
+		pods:      make(map[string]*podInformer),
+	}
+}
+
+// Register 服务由kubernetes注册，不做任何处理
+func (r *Registry) Register(_ context.Context, _ *transport.ServiceInfo) error {
+	return nil
+}
+
+// Deregister 服务由kubernetes注销，不做任何处理
+func (r *Registry) Deregister(_ context.Context, _ *transport.ServiceInfo) error {
+	return nil
+}
+
+// GetService 获取服务的全部实例，服务名格式为 [namespace/]name[:port]，port 为端口名称或端口号
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	t, err := r.parseTarget(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.clientset.DiscoveryV1().EndpointSlices(t.namespace).List(ctx, metav1.ListOptions{LabelSelector: t.selector()})
+	if err != nil {
+		return nil, err
+	}
+	slices := make([]*discoveryv1.EndpointSlice, 0, len(list.Items))
+	for i := range list.Items {
+		slices = append(slices, &list.Items[i])
+	}
+	// 按服务的标签选择器一次获取实例所在的pod，避免按节点逐个请求
+	pods := make(map[string]*corev1.Pod)
+	if podList, err := r.listPods(ctx, t); err != nil {
+		r.options.logger.Warnf("[registry.kubernetes] failed to list pods of %s/%s: %v", t.namespace, t.name, err)
+	} else {
+		for i := range podList {
+			pods[podList[i].Name] = &podList[i]
+		}
+	}
+	return r.services(t, slices, func(name string) (*corev1.Pod, bool) {
+		pod, ok := pods[name]
+		return pod, ok
+	}), nil
+}
+
+// listPods 获取服务标签选择器匹配的pod，服务未配置选择器时实例不引用pod，直接返回
+func (r *Registry) listPods(ctx context.Context, t target) ([]corev1.Pod, error) {
+	svc, err := r.clientset.CoreV1().Services(t.namespace).Get(ctx, t.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	list, err := r.clientset.CoreV1().Pods(t.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// acquirePods 获取命名空间共享的pod缓存，不存在时创建并启动
+func (r *Registry) acquirePods(namespace string) cache.SharedIndexInformer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pods[namespace]; ok {
+		p.refs++
+		return p.informer
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pods := r.clientset.CoreV1().Pods(namespace)
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return pods.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return pods.Watch(ctx, options)
+		},
+	}, &corev1.Pod{}, 0, cache.Indexers{})
+	r.pods[namespace] = &podInformer{informer: informer, cancel: cancel, refs: 1}
+	go informer.Run(ctx.Done())
+	return informer
+}
+
+// releasePods 释放命名空间共享的pod缓存
+func (r *Registry) releasePods(namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pods[namespace]; ok {
+		if p.refs--; p.refs <= 0 {
+			p.cancel()
+			delete(r.pods, namespace)
+		}
+	}
+}
+
+// Watch 监听服务的 EndpointSlice 变化，服务名格式同 GetService
+func (r *Registry) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	t, err := r.parseTarget(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return newWatcher(ctx, r, t)
+}
+
+// target 发现目标
+type target struct {
+	namespace string
+	name      string
+	port      string
+}
+
+// parseTarget 解析 [namespace/]name[:port] 格式的发现目标
+func (r *Registry) parseTarget(serviceName string) (target, error) {
+	t := target{namespace: r.options.Namespace}
+	if i := strings.LastIndex(serviceName, ":"); i >= 0 {
+		serviceName, t.port = serviceName[:i], serviceName[i+1:]
+	}
+	if i := strings.Index(serviceName, "/"); i >= 0 {
+		t.namespace, serviceName = serviceName[:i], serviceName[i+1:]
+	}
+	t.name = serviceName
+	if t.name == "" || t.namespace == "" || strings.Contains(t.name, "/") {
+		return t, errors.New("invalid kubernetes discovery target, want [namespace/]name[:port]")
+	}
+	return t, nil
+}
+
+// selector 服务的 EndpointSlice 标签选择器
+func (t target) selector() string {
+	return discoveryv1.LabelServiceName + "=" + t.name
+}
+
+// matchPort 端口是否匹配发现目标，未指定端口时匹配全部端口
+func (t target) matchPort(port discoveryv1.EndpointPort) bool {
+	if t.port == "" {
+		return true
+	}
+	if port.Name != nil && *port.Name == t.port {
+		return true
+	}
+	return port.Port != nil && strconv.Itoa(int(*port.Port)) == t.port
+}
+
+// podGetter 按名称获取pod
+type podGetter func(name string) (*corev1.Pod, bool)
+
+// services EndpointSlice 转换为服务信息，同一pod的多个端口合并为一个实例
+func (r *Registry) services(t target, slices []*discoveryv1.EndpointSlice, getPod podGetter) []*transport.ServiceInfo {
+	instances := make(map[string]*transport.ServiceInfo)
+	for _, slice := range slices {
+		if slice.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if !r.options.IncludeNotReady && endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			for _, address := range endpoint.Addresses {
+				id := address
+				if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+					id = endpoint.TargetRef.Name
+				}
+				ins, ok := instances[id]
+				if !ok {
+					ins = &transport.ServiceInfo{ID: id, Name: t.name, Metadata: map[string]string{}}
+					if endpoint.Zone != nil {
+						ins.Metadata[filter.MetadataZone] = *endpoint.Zone
+					}
+					if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+						if pod, ok := getPod(endpoint.TargetRef.Name); ok {
+							r.podMetadata(pod, ins)
+						}
+					}
+					instances[id] = ins
+				}
+				for _, port := range slice.Ports {
+					if port.Port == nil || !t.matchPort(port) {
+						continue
+					}
+					ins.Endpoints = append(ins.Endpoints, fmt.Sprintf("%s://%s", r.scheme(port), net.JoinHostPort(address, strconv.Itoa(int(*port.Port)))))
+				}
+			}
+		}
+	}
+	items := make([]*transport.ServiceInfo, 0, len(instances))
+	for _, ins := range instances {
+		if len(ins.Endpoints) == 0 {
+			continue
+		}
+		sort.Strings(ins.Endpoints)
+		items = append(items, ins)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// podMetadata 将pod的标签与指定前缀的注解写入元数据，版本取自版本标签
+func (r *Registry) podMetadata(pod *corev1.Pod, ins *transport.ServiceInfo) {
+	for k, v := range pod.Labels {
+		ins.Metadata[k] = v
+	}
+	if r.options.AnnotationPrefix != "" {
+		for k, v := range pod.Annotations {
+			if strings.HasPrefix(k, r.options.AnnotationPrefix) {
+				ins.Metadata[strings.TrimPrefix(k, r.options.AnnotationPrefix)] = v
+			}
+		}
+	}
+	ins.Version = pod.Labels[r.options.VersionLabel]
+}
+
+// scheme 服务地址的协议，依次使用配置的协议、端口的 appProtocol、端口名称前缀，默认为http
+func (r *Registry) scheme(port discoveryv1.EndpointPort) string {
+	if r.options.Scheme != "" {
+		return r.options.Scheme
+	}
+	if port.AppProtocol != nil && *port.AppProtocol != "" && !strings.Contains(*port.AppProtocol, "/") {
+		return strings.ToLower(*port.AppProtocol)
+	}
+	if port.Name != nil && *port.Name != "" {
+		name, _, _ := strings.Cut(*port.Name, "-")
+		switch name = strings.ToLower(name); name {
+		case "grpc", "grpcs", "http", "https":
+			return name
+		}
+	}
+	return "http"
+}
@@ -0,0 +1,290 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func pod(name string, labels, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   "prod",
+		Labels:      labels,
+		Annotations: annotations,
+	}}
+}
+
+func service(name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod"},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+}
+
+func endpoint(podName, address string, ready bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{address},
+		Conditions: discoveryv1.EndpointConditions{Ready: ptr(ready)},
+		TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: podName, Namespace: "prod"},
+		Zone:       ptr("zone-a"),
+	}
+}
+
+func endpointSlice(name string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "prod",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "greeter"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports: []discoveryv1.EndpointPort{
+			{Name: ptr("grpc"), Port: ptr(int32(9000))},
+			{Name: ptr("http-api"), Port: ptr(int32(8000))},
+		},
+	}
+}
+
+func next(t *testing.T, w transport.Watcher) []*transport.ServiceInfo {
+	type result struct {
+		services []*transport.ServiceInfo
+		err      error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		services, err := w.Next()
+		ch <- result{services, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		return r.services
+	case <-time.After(3 * time.Second):
+		t.Fatal("watcher next timeout")
+	}
+	return nil
+}
+
+func TestGetService(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		service("greeter", map[string]string{"app": "greeter"}),
+		pod("greeter-1", map[string]string{"app.kubernetes.io/version": "v1.2.0", "app": "greeter"}, map[string]string{"ceres.io/weight": "10", "other": "x"}),
+		pod("other-1", map[string]string{"app": "other"}, nil),
+		endpointSlice("greeter-abc", endpoint("greeter-1", "10.0.0.1", true), endpoint("greeter-2", "10.0.0.2", false)),
+	)
+	r := New(WithClientset(clientset), WithNamespace("default"))
+	ctx := context.Background()
+	services, err := r.GetService(ctx, "prod/greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("want 1 ready instance, got %d", len(services))
+	}
+	ins := services[0]
+	if ins.ID != "greeter-1" || ins.Name != "greeter" || ins.Version != "v1.2.0" {
+		t.Fatalf("unexpected instance %+v", ins)
+	}
+	if ins.Metadata["app"] != "greeter" || ins.Metadata["weight"] != "10" || ins.Metadata["zone"] != "zone-a" {
+		t.Fatalf("unexpected metadata %v", ins.Metadata)
+	}
+	if _, ok := ins.Metadata["other"]; ok {
+		t.Fatal("annotations without prefix must be ignored")
+	}
+	// 只获取服务选择器匹配的pod
+	for _, action := range clientset.Actions() {
+		if list, ok := action.(k8stesting.ListAction); ok && action.GetResource().Resource == "pods" {
+			if selector := list.GetListRestrictions().Labels.String(); selector != "app=greeter" {
+				t.Fatalf("want pods listed by service selector, got %q", selector)
+			}
+		}
+	}
+	if len(ins.Endpoints) != 2 || ins.Endpoints[0] != "grpc://10.0.0.1:9000" || ins.Endpoints[1] != "http://10.0.0.1:8000" {
+		t.Fatalf("unexpected endpoints %v", ins.Endpoints)
+	}
+
+	// 指定端口名称
+	if services, _ = r.GetService(ctx, "prod/greeter:grpc"); len(services) != 1 || len(services[0].Endpoints) != 1 || services[0].Endpoints[0] != "grpc://10.0.0.1:9000" {
+		t.Fatalf("unexpected services %v", services)
+	}
+	// 默认命名空间中不存在
+	if services, _ = r.GetService(ctx, "greeter"); len(services) != 0 {
+		t.Fatalf("want no instance in default namespace, got %v", services)
+	}
+	if _, err = r.GetService(ctx, "prod/"); err == nil {
+		t.Fatal("want invalid target error")
+	}
+	// 包含未就绪节点
+	r = New(WithClientset(clientset), WithIncludeNotReady(true))
+	if services, _ = r.GetService(ctx, "prod/greeter:8000"); len(services) != 2 {
+		t.Fatalf("want 2 instances, got %d", len(services))
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	r := New(WithClientset(clientset), WithNamespace("prod"))
+	ctx := context.Background()
+	w, err := r.Watch(ctx, "greeter:grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 0 {
+		t.Fatalf("want empty services, got %v", services)
+	}
+	slices := clientset.DiscoveryV1().EndpointSlices("prod")
+	slice := endpointSlice("greeter-abc", endpoint("greeter-1", "10.0.0.1", true))
+	if _, err = slices.Create(ctx, slice, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 1 || services[0].Endpoints[0] != "grpc://10.0.0.1:9000" {
+		t.Fatalf("want created instance, got %v", services)
+	}
+	slice.Endpoints = append(slice.Endpoints, endpoint("greeter-2", "10.0.0.2", true))
+	if _, err = slices.Update(ctx, slice, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 2 {
+		t.Fatalf("want 2 instances, got %v", services)
+	}
+	if err = slices.Delete(ctx, slice.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 0 {
+		t.Fatalf("want empty services, got %v", services)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	_ = w.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must unblock next")
+	}
+}
+
+// TestWatcherPodMetadata 实例元数据来自pod缓存，不逐个请求pod
+func TestWatcherPodMetadata(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		pod("greeter-1", map[string]string{"app.kubernetes.io/version": "v1"}, nil),
+		endpointSlice("greeter-abc", endpoint("greeter-1", "10.0.0.1", true)),
+	)
+	r := New(WithClientset(clientset), WithNamespace("prod"))
+	ctx := context.Background()
+	w, err := r.Watch(ctx, "greeter:grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if services := next(t, w); len(services) != 1 || services[0].Version != "v1" {
+		t.Fatalf("want instance with pod version, got %v", services)
+	}
+	// 引用的pod变化时更新元数据
+	if _, err = clientset.CoreV1().Pods("prod").Update(ctx, pod("greeter-1", map[string]string{"app.kubernetes.io/version": "v2"}, nil), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 1 || services[0].Version != "v2" {
+		t.Fatalf("want updated pod version, got %v", services)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetResource().Resource == "pods" && action.GetVerb() == "get" {
+			t.Fatalf("unexpected pod get request %v", action)
+		}
+	}
+}
+
+// TestWatcherSharePods 同一命名空间的监听者共享pod缓存，全部停止后释放
+func TestWatcherSharePods(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		pod("greeter-1", map[string]string{"app.kubernetes.io/version": "v1"}, nil),
+		endpointSlice("greeter-abc", endpoint("greeter-1", "10.0.0.1", true)),
+	)
+	r := New(WithClientset(clientset), WithNamespace("prod"))
+	ctx := context.Background()
+	w1, err := r.Watch(ctx, "greeter:grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, err := r.Watch(ctx, "greeter:http-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w1); len(services) != 1 || services[0].Version != "v1" {
+		t.Fatalf("want instance with pod version, got %v", services)
+	}
+	if services := next(t, w2); len(services) != 1 || services[0].Version != "v1" {
+		t.Fatalf("want instance with pod version, got %v", services)
+	}
+	lists := 0
+	for _, action := range clientset.Actions() {
+		if action.GetResource().Resource == "pods" && action.GetVerb() == "list" {
+			lists++
+		}
+	}
+	if lists != 1 {
+		t.Fatalf("want pods listed once, got %d", lists)
+	}
+	_ = w1.Stop()
+	_ = w2.Stop()
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		n := len(r.pods)
+		r.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("shared pod informer must be released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := New(WithClientset(fake.NewSimpleClientset()))
+	ins := &transport.ServiceInfo{ID: "1", Name: "greeter"}
+	if err := r.Register(context.Background(), ins); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Deregister(context.Background(), ins); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sync/atomic"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	registry *Registry
+	target   target
+	informer cache.SharedIndexInformer
+	pods     cache.SharedIndexInformer // 命名空间共享的pod缓存，用于读取实例元数据
+	refs     atomic.Value              // 当前实例引用的pod名称
+	event    chan struct{}
+	synced   bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func newWatcher(ctx context.Context, r *Registry, t target) (*watcher, error) {
+	w := &watcher{
+		registry: r,
+		target:   t,
+		event:    make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	slices := r.clientset.DiscoveryV1().EndpointSlices(t.namespace)
+	w.informer = cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = t.selector()
+			return slices.List(w.ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = t.selector()
+			return slices.Watch(w.ctx, options)
+		},
+	}, &discoveryv1.EndpointSlice{}, 0, cache.Indexers{})
+	w.pods = r.acquirePods(t.namespace)
+	notify := func(interface{}) {
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
+	// 只有实例引用的pod变化时才需要更新元数据
+	notifyPod := func(obj interface{}) {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			if refs, _ := w.refs.Load().(map[string]struct{}); refs != nil {
+				if _, ok = refs[pod.Name]; ok {
+					notify(obj)
+				}
+			}
+		}
+	}
+	if _, err := w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notify,
+		UpdateFunc: func(_, obj interface{}) { notify(obj) },
+		DeleteFunc: notify,
+	}); err != nil {
+		w.cancel()
+		r.releasePods(t.namespace)
+		return nil, err
+	}
+	handler, err := w.pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notifyPod,
+		UpdateFunc: func(_, obj interface{}) { notifyPod(obj) },
+	})
+	if err != nil {
+		w.cancel()
+		r.releasePods(t.namespace)
+		return nil, err
+	}
+	go w.informer.Run(w.ctx.Done())
+	// 停止监听后释放共享的pod缓存
+	go func() {
+		<-w.ctx.Done()
+		_ = w.pods.RemoveEventHandler(handler)
+		r.releasePods(t.namespace)
+	}()
+	return w, nil
+}
+
+// Next 首次调用在缓存同步后返回当前实例列表，之后等待 EndpointSlice 变化
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	if !w.synced {
+		if !cache.WaitForCacheSync(w.ctx.Done(), w.informer.HasSynced, w.pods.HasSynced) {
+			return nil, w.ctx.Err()
+		}
+		w.synced = true
+		// 同步期间产生的事件已包含在当前列表中
+		select {
+		case <-w.event:
+		default:
+		}
+	} else {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.event:
+		}
+	}
+	objs := w.informer.GetStore().List()
+	slices := make([]*discoveryv1.EndpointSlice, 0, len(objs))
+	for _, obj := range objs {
+		if slice, ok := obj.(*discoveryv1.EndpointSlice); ok {
+			slices = append(slices, slice)
+		}
+	}
+	services := w.registry.services(w.target, slices, w.getPod)
+	refs := make(map[string]struct{}, len(services))
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				refs[endpoint.TargetRef.Name] = struct{}{}
+			}
+		}
+	}
+	w.refs.Store(refs)
+	return services, nil
+}
+
+// getPod 从本地缓存中获取pod
+func (w *watcher) getPod(name string) (*corev1.Pod, bool) {
+	obj, ok, err := w.pods.GetStore().GetByKey(w.target.namespace + "/" + name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	pod, ok := obj.(*corev1.Pod)
+	return pod, ok
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
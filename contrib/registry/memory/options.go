@@ -0,0 +1,71 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"time"
+)
+
+type Option func(o *Options)
+
+// Options 内存注册中心配置
+type Options struct {
+	TTL time.Duration `json:"ttl"` // 实例存活时间，超过该时间未重新注册的实例会被移除，默认为0，不过期
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.memory"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithTTL 设置实例存活时间
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建注册中心
+func (o *Options) Build() *Registry {
+	return NewWithOptions(o)
+}
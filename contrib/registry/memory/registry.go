@@ -0,0 +1,283 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ transport.Registry = (*Registry)(nil)
+	_ transport.Discover = (*Registry)(nil)
+)
+
+// Registry 线程安全的内存注册中心，同时实现了服务注册与服务发现，适用于测试与单进程部署
+type Registry struct {
+	options  *Options
+	mu       sync.RWMutex
+	services map[string]map[string]*instance // 服务名 -> 实例标识 -> 实例
+	watchers map[string]map[*watcher]struct{}
+}
+
+// instance 注册的实例
+type instance struct {
+	info  *transport.ServiceInfo
+	timer *time.Timer // 过期定时器
+}
+
+// New 创建内存注册中心
+func New(opts ...Option) *Registry {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建内存注册中心
+func NewWithOptions(options *Options) *Registry {
+	return &Registry{
+		options:  options,
+		services: make(map[string]map[string]*instance),
+		watchers: make(map[string]map[*watcher]struct{}),
+	}
+}
+
+// Register 注册服务，相同实例重复注册会刷新实例信息与存活时间
+func (r *Registry) Register(ctx context.Context, service *transport.ServiceInfo) error {
+	if service == nil || service.Name == "" {
+		return errors.New("ServiceInfo.name is empty")
+	}
+	info := clone(service)
+	key := instanceKey(info)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instances, ok := r.services[info.Name]
+	if !ok {
+		instances = make(map[string]*instance)
+		r.services[info.Name] = instances
+	}
+	ins, ok := instances[key]
+	if !ok {
+		ins = &instance{}
+		instances[key] = ins
+	}
+	ins.info = info
+	if r.options.TTL > 0 {
+		if ins.timer != nil {
+			ins.timer.Stop()
+		}
+		ins.timer = time.AfterFunc(r.options.TTL, func() {
+			r.expire(info.Name, key, ins)
+		})
+	}
+	r.notify(info.Name, nil)
+	return nil
+}
+
+// Deregister 注销服务
+func (r *Registry) Deregister(ctx context.Context, service *transport.ServiceInfo) error {
+	if service == nil || service.Name == "" {
+		return errors.New("ServiceInfo.name is empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(service.Name, instanceKey(service))
+	return nil
+}
+
+// GetService 获取服务的全部实例
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.snapshot(serviceName), nil
+}
+
+// Watch 监听服务，首次调用 Next 立即返回当前的实例列表，之后在实例变化时返回
+func (r *Registry) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	w := &watcher{
+		registry:    r,
+		serviceName: serviceName,
+		events:      make(chan error, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.events <- nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	watchers, ok := r.watchers[serviceName]
+	if !ok {
+		watchers = make(map[*watcher]struct{})
+		r.watchers[serviceName] = watchers
+	}
+	watchers[w] = struct{}{}
+	return w, nil
+}
+
+// Set 替换服务的全部实例并通知监听者，用于模拟注册中心推送的变更
+func (r *Registry) Set(serviceName string, services ...*transport.ServiceInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ins := range r.services[serviceName] {
+		if ins.timer != nil {
+			ins.timer.Stop()
+		}
+	}
+	instances := make(map[string]*instance, len(services))
+	for _, service := range services {
+		info := clone(service)
+		info.Name = serviceName
+		instances[instanceKey(info)] = &instance{info: info}
+	}
+	r.services[serviceName] = instances
+	r.notify(serviceName, nil)
+}
+
+// Notify 在实例没有变化的情况下唤醒服务的全部监听者
+func (r *Registry) Notify(serviceName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify(serviceName, nil)
+}
+
+// InjectError 使服务监听者的下一次 Next 返回指定错误，用于模拟注册中心故障
+func (r *Registry) InjectError(serviceName string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify(serviceName, err)
+}
+
+// Close 停止全部过期定时器与监听者
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, instances := range r.services {
+		for _, ins := range instances {
+			if ins.timer != nil {
+				ins.timer.Stop()
+			}
+		}
+	}
+	for _, watchers := range r.watchers {
+		for w := range watchers {
+			w.cancel()
+		}
+	}
+	r.watchers = make(map[string]map[*watcher]struct{})
+	return nil
+}
+
+// expire 实例过期，实例在此期间重新注册时不做处理
+func (r *Registry) expire(serviceName, key string, ins *instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.services[serviceName][key]; ok && current == ins {
+		r.remove(serviceName, key)
+	}
+}
+
+// remove 移除实例，调用方需持有写锁
+func (r *Registry) remove(serviceName, key string) {
+	instances, ok := r.services[serviceName]
+	if !ok {
+		return
+	}
+	ins, ok := instances[key]
+	if !ok {
+		return
+	}
+	if ins.timer != nil {
+		ins.timer.Stop()
+	}
+	delete(instances, key)
+	if len(instances) == 0 {
+		delete(r.services, serviceName)
+	}
+	r.notify(serviceName, nil)
+}
+
+// notify 通知服务的全部监听者，调用方需持有写锁
+func (r *Registry) notify(serviceName string, err error) {
+	for w := range r.watchers[serviceName] {
+		if err != nil {
+			// 错误需要送达，替换掉尚未消费的变更通知
+			select {
+			case <-w.events:
+			default:
+			}
+		}
+		select {
+		case w.events <- err:
+		default:
+		}
+	}
+}
+
+// snapshot 获取服务实例列表的副本，按实例标识排序，调用方需持有读锁
+func (r *Registry) snapshot(serviceName string) []*transport.ServiceInfo {
+	instances := r.services[serviceName]
+	keys := make([]string, 0, len(instances))
+	for key := range instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := make([]*transport.ServiceInfo, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, clone(instances[key].info))
+	}
+	return res
+}
+
+func (r *Registry) removeWatcher(w *watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if watchers, ok := r.watchers[w.serviceName]; ok {
+		delete(watchers, w)
+		if len(watchers) == 0 {
+			delete(r.watchers, w.serviceName)
+		}
+	}
+}
+
+// instanceKey 实例唯一标识，优先使用实例ID
+func instanceKey(info *transport.ServiceInfo) string {
+	if info.ID != "" {
+		return info.ID
+	}
+	return strings.Join(info.Endpoints, ",")
+}
+
+// clone 复制服务信息，避免调用方修改注册中心中的数据
+func clone(info *transport.ServiceInfo) *transport.ServiceInfo {
+	res := &transport.ServiceInfo{
+		ID:        info.ID,
+		Name:      info.Name,
+		Version:   info.Version,
+		Endpoints: append([]string(nil), info.Endpoints...),
+	}
+	if info.Metadata != nil {
+		res.Metadata = make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			res.Metadata[k] = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,183 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/app"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/go-ceres/ceres/pkg/transport/grpc"
+	"github.com/go-ceres/ceres/pkg/transport/http"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"testing"
+	"time"
+)
+
+func next(t *testing.T, w transport.Watcher) []*transport.ServiceInfo {
+	type result struct {
+		services []*transport.ServiceInfo
+		err      error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		services, err := w.Next()
+		ch <- result{services, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		return r.services
+	case <-time.After(time.Second):
+		t.Fatal("watcher next timeout")
+	}
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+	w, err := r.Watch(ctx, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 0 {
+		t.Fatalf("want empty services, got %v", services)
+	}
+	ins := &transport.ServiceInfo{ID: "1", Name: "greeter", Endpoints: []string{"grpc://127.0.0.1:9000"}}
+	if err := r.Register(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 1 || services[0].ID != "1" {
+		t.Fatalf("want registered instance, got %v", services)
+	}
+	// 修改返回结果不影响注册中心
+	services, _ := r.GetService(ctx, "greeter")
+	services[0].Endpoints[0] = "grpc://changed"
+	if services, _ = r.GetService(ctx, "greeter"); services[0].Endpoints[0] != "grpc://127.0.0.1:9000" {
+		t.Fatal("registry data must not be shared")
+	}
+
+	r.InjectError("greeter", errors.New("unavailable"))
+	if _, err := w.Next(); err == nil || err.Error() != "unavailable" {
+		t.Fatalf("want injected error, got %v", err)
+	}
+	r.Set("greeter", &transport.ServiceInfo{ID: "2"}, &transport.ServiceInfo{ID: "3"})
+	if services := next(t, w); len(services) != 2 || services[0].Name != "greeter" {
+		t.Fatalf("want replaced instances, got %v", services)
+	}
+	if err := r.Deregister(ctx, &transport.ServiceInfo{ID: "2", Name: "greeter"}); err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 1 || services[0].ID != "3" {
+		t.Fatalf("want remaining instance, got %v", services)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	_ = w.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must unblock next")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	r := New(WithTTL(50 * time.Millisecond))
+	defer r.Close()
+	ctx := context.Background()
+	ins := &transport.ServiceInfo{ID: "1", Name: "greeter"}
+	_ = r.Register(ctx, ins)
+	// 在过期前重新注册会刷新存活时间
+	time.Sleep(30 * time.Millisecond)
+	_ = r.Register(ctx, ins)
+	time.Sleep(30 * time.Millisecond)
+	if services, _ := r.GetService(ctx, "greeter"); len(services) != 1 {
+		t.Fatal("instance must be kept alive by re-registration")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if services, _ := r.GetService(ctx, "greeter"); len(services) != 0 {
+		t.Fatal("instance must expire")
+	}
+}
+
+func TestApplication(t *testing.T) {
+	r := New()
+	grpcSrv := grpc.NewServer(grpc.WithServerAddress("127.0.0.1:0"), grpc.WithServerHealth(true))
+	httpSrv := http.NewServer(http.WithServerAddress("127.0.0.1:0"))
+	httpSrv.GET("/ping", func(ctx *http.Context) error {
+		return ctx.SendString("pong")
+	})
+	application := app.New(
+		app.WithName("greeter"),
+		app.WithId("greeter-1"),
+		app.HideBanner(),
+		app.WithRegistry(r),
+		app.WithTransport(grpcSrv, httpSrv),
+	)
+	go func() {
+		_ = application.Run()
+	}()
+	defer application.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.NewClient(
+		grpc.WithClientEndpoint("discovery:///greeter"),
+		grpc.WithClientDiscovery(r),
+		grpc.WithClientBlock(false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}, ggrpc.WaitForReady(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("unexpected health status %v", reply.Status)
+	}
+
+	client, err := http.NewClient(
+		http.WithClientEndpoint("discovery:///greeter"),
+		http.WithClientDiscovery(r),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, resp := http.AcquireRequest(), http.AcquireResponse()
+	defer http.ReleaseRequest(req)
+	defer http.ReleaseResponse(resp)
+	req.SetRequestURI("/ping")
+	req.Header.SetMethod(http.MethodGet)
+	if err := client.Do(ctx, req, resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body()) != "pong" {
+		t.Fatalf("unexpected body %s", resp.Body())
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	registry    *Registry
+	serviceName string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	events      chan error
+}
+
+// Next 阻塞直到服务实例变化，监听者停止后返回 context.Canceled
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case err := <-w.events:
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.registry.mu.RLock()
+	defer w.registry.mu.RUnlock()
+	return w.registry.snapshot(w.serviceName), nil
+}
+
+// Stop 停止监听，阻塞中的 Next 会立即返回
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.registry.removeWatcher(w)
+	return nil
+}
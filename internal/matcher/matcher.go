@@ -16,60 +16,262 @@
 package matcher
 
 import (
+	"fmt"
 	"github.com/go-ceres/ceres/pkg/transport"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // Matcher 中间件匹配器
 type Matcher interface {
+	// Use 添加全局中间件，按添加顺序最先执行
 	Use(mw ...transport.Middleware)
+	// Add 按选择器添加中间件，选择器语法见 parseSelector
 	Add(selector string, mw ...transport.Middleware)
-	Match(operator string) []transport.Middleware
+	// AddRule 添加中间件匹配规则
+	AddRule(rule Rule)
+	// Match 根据操作名匹配中间件
+	Match(operation string) []transport.Middleware
+	// MatchMethod 根据请求方法与操作名匹配中间件，任一操作名命中即视为命中
+	MatchMethod(method string, operations ...string) []transport.Middleware
+	// Rules 按执行顺序返回全部规则，全局中间件作为第一条无选择器的规则返回
+	Rules() []Rule
+}
+
+// Rule 中间件匹配规则
+type Rule struct {
+	Selectors  []string               // 匹配的选择器，任一命中即匹配，为空时匹配全部
+	Excludes   []string               // 排除的选择器，任一命中即不匹配
+	Priority   int                    // 优先级，数值越小越先执行，相同时按添加顺序执行
+	Middleware []transport.Middleware // 中间件
 }
 
 // New 创建
 func New() Matcher {
-	return &matcher{
-		matchs: make(map[string][]transport.Middleware),
-	}
+	return &matcher{}
 }
 
 type matcher struct {
-	prefix []string
-	data   []transport.Middleware
-	matchs map[string][]transport.Middleware
+	mu    sync.RWMutex
+	data  []transport.Middleware
+	rules []*rule
 }
 
+// rule 解析后的匹配规则
+type rule struct {
+	raw        Rule
+	seq        int
+	priority   int
+	includes   []*selector
+	excludes   []*selector
+	middleware []transport.Middleware
+}
+
+// Use 添加全局中间件
 func (m *matcher) Use(ms ...transport.Middleware) {
-	m.data = ms
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = append(m.data, ms...)
 }
 
-// Add 添加中间件到匹配器
+// Add 添加中间件到匹配器，多个选择器使用逗号分隔，以 ! 开头的选择器表示排除，
+// 如 "!/health,!/login" 表示除 /health 与 /login 之外的全部操作，
+// 选择器为空时与旧版本一致不匹配任何操作，如需匹配全部操作请使用 "*"
 func (m *matcher) Add(selector string, mw ...transport.Middleware) {
-	if strings.HasPrefix(selector, "*") {
-		selector = strings.TrimPrefix(selector, "*")
-		m.prefix = append(m.prefix, selector)
-		sort.Slice(m.prefix, func(i, j int) bool {
-			return m.prefix[i] > m.prefix[j]
-		})
+	r := Rule{Middleware: mw}
+	for _, s := range splitSelector(selector) {
+		if strings.HasPrefix(s, "!") {
+			r.Excludes = append(r.Excludes, s[1:])
+		} else {
+			r.Selectors = append(r.Selectors, s)
+		}
+	}
+	if len(r.Selectors) == 0 && len(r.Excludes) == 0 {
+		return
 	}
-	m.matchs[selector] = mw
+	m.AddRule(r)
+}
+
+// AddRule 添加中间件匹配规则，选择器错误时panic
+func (m *matcher) AddRule(r Rule) {
+	parsed := &rule{
+		raw:        r,
+		priority:   r.Priority,
+		middleware: r.Middleware,
+	}
+	for _, s := range r.Selectors {
+		parsed.includes = append(parsed.includes, mustParseSelector(s))
+	}
+	for _, s := range r.Excludes {
+		parsed.excludes = append(parsed.excludes, mustParseSelector(s))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parsed.seq = len(m.rules)
+	m.rules = append(m.rules, parsed)
+	sort.SliceStable(m.rules, func(i, j int) bool {
+		if m.rules[i].priority != m.rules[j].priority {
+			return m.rules[i].priority < m.rules[j].priority
+		}
+		return m.rules[i].seq < m.rules[j].seq
+	})
 }
 
 // Match 匹配
 func (m *matcher) Match(operation string) []transport.Middleware {
+	return m.MatchMethod("", operation)
+}
+
+// MatchMethod 匹配，全局中间件在前，其后为按优先级排序的全部命中规则的中间件
+func (m *matcher) MatchMethod(method string, operations ...string) []transport.Middleware {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	ms := make([]transport.Middleware, 0, len(m.data))
+	ms = append(ms, m.data...)
+	for _, r := range m.rules {
+		if r.match(method, operations) {
+			ms = append(ms, r.middleware...)
+		}
+	}
+	return ms
+}
+
+// Rules 返回全部规则
+func (m *matcher) Rules() []Rule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rules := make([]Rule, 0, len(m.rules)+1)
 	if len(m.data) > 0 {
-		ms = append(ms, m.data...)
+		rules = append(rules, Rule{Middleware: m.data})
 	}
-	if next, ok := m.matchs[operation]; ok {
-		return append(ms, next...)
+	for _, r := range m.rules {
+		rules = append(rules, r.raw)
 	}
-	for _, prefix := range m.prefix {
-		if strings.HasPrefix(operation, prefix) {
-			return append(ms, m.matchs[prefix]...)
+	return rules
+}
+
+func (r *rule) match(method string, operations []string) bool {
+	for _, s := range r.excludes {
+		if s.matchAny(method, operations) {
+			return false
 		}
 	}
-	return ms
+	if len(r.includes) == 0 {
+		return true
+	}
+	for _, s := range r.includes {
+		if s.matchAny(method, operations) {
+			return true
+		}
+	}
+	return false
+}
+
+// selector 单个选择器
+type selector struct {
+	method string
+	exact  string
+	regex  *regexp.Regexp
+}
+
+// parseSelector 解析选择器，格式为 [方法 ]模式，模式支持：
+//   - 精确匹配：/helloworld.Greeter/SayHello
+//   - 前缀：以 * 开头且不含其他通配符时，兼容旧版本按去掉 * 后的前缀匹配，如 */helloworld.Greeter/
+//   - 通配符：* 匹配任意字符，? 匹配单个字符，如 /helloworld.Greeter/*、*
+//   - 正则：以 regex: 开头，如 regex:^/api/v\d+/
+//
+// 带方法的选择器只匹配对应的http请求方法，如 "GET /user/*"
+func parseSelector(s string) (*selector, error) {
+	s = strings.TrimSpace(s)
+	sel := &selector{}
+	if i := strings.IndexByte(s, ' '); i > 0 && isMethod(s[:i]) {
+		if method := strings.ToUpper(s[:i]); method != "ANY" {
+			sel.method = method
+		}
+		s = strings.TrimSpace(s[i+1:])
+	}
+	if s == "" {
+		return nil, fmt.Errorf("matcher: empty selector")
+	}
+	switch {
+	case strings.HasPrefix(s, "regex:"):
+		re, err := regexp.Compile(s[len("regex:"):])
+		if err != nil {
+			return nil, fmt.Errorf("matcher: invalid regex selector %q: %w", s, err)
+		}
+		sel.regex = re
+	case strings.HasPrefix(s, "*") && !strings.ContainsAny(s[1:], "*?"):
+		sel.regex = regexp.MustCompile("^" + regexp.QuoteMeta(s[1:]))
+	case strings.ContainsAny(s, "*?"):
+		var b strings.Builder
+		b.WriteByte('^')
+		for _, c := range s {
+			switch c {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteByte('.')
+			default:
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		b.WriteByte('$')
+		sel.regex = regexp.MustCompile(b.String())
+	default:
+		sel.exact = s
+	}
+	return sel, nil
+}
+
+// mustParseSelector 同 parseSelector，解析失败时panic
+func mustParseSelector(s string) *selector {
+	sel, err := parseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+func (s *selector) matchAny(method string, operations []string) bool {
+	if s.method != "" && !strings.EqualFold(s.method, method) {
+		return false
+	}
+	for _, op := range operations {
+		if s.regex != nil {
+			if s.regex.MatchString(op) {
+				return true
+			}
+		} else if s.exact == op {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSelector 使用逗号拆分选择器，正则选择器中可能包含逗号，不做拆分
+func splitSelector(s string) []string {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "!")
+	if i := strings.IndexByte(trimmed, ' '); i > 0 && isMethod(trimmed[:i]) {
+		trimmed = strings.TrimSpace(trimmed[i+1:])
+	}
+	if strings.HasPrefix(trimmed, "regex:") {
+		return []string{strings.TrimSpace(s)}
+	}
+	var res []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
+
+func isMethod(s string) bool {
+	switch strings.ToUpper(s) {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE", "ANY":
+		return true
+	}
+	return false
 }
@@ -0,0 +1,117 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matcher
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"reflect"
+	"testing"
+)
+
+func named(name string, trace *[]string) transport.Middleware {
+	return func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			*trace = append(*trace, name)
+			return handler(ctx, req)
+		}
+	}
+}
+
+func run(ms []transport.Middleware) {
+	h := transport.MiddlewareChain(ms...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, _ = h(context.Background(), nil)
+}
+
+func TestMatch(t *testing.T) {
+	var trace []string
+	m := New()
+	m.Use(named("logging", &trace))
+	m.Use(named("recovery", &trace))
+	m.Add("!/health,!/login", named("auth", &trace))
+	m.Add("/helloworld.Greeter/*", named("glob", &trace))
+	m.Add("regex:^/helloworld\\.Greeter/Say(Hello|Bye)$", named("regex", &trace))
+	m.Add("POST /user/*", named("post", &trace))
+	m.AddRule(Rule{Selectors: []string{"*"}, Priority: -1, Middleware: []transport.Middleware{named("first", &trace)}})
+
+	cases := []struct {
+		method     string
+		operations []string
+		want       []string
+	}{
+		{"", []string{"/health"}, []string{"logging", "recovery", "first"}},
+		{"", []string{"/helloworld.Greeter/SayHello"}, []string{"logging", "recovery", "first", "auth", "glob", "regex"}},
+		{"", []string{"/helloworld.Greeter/Ping"}, []string{"logging", "recovery", "first", "auth", "glob"}},
+		{"POST", []string{"/user.User/Create", "/user/create"}, []string{"logging", "recovery", "first", "auth", "post"}},
+		{"GET", []string{"/user/create"}, []string{"logging", "recovery", "first", "auth"}},
+		{"GET", []string{"/user.User/Login", "/login"}, []string{"logging", "recovery", "first"}},
+	}
+	for _, c := range cases {
+		trace = trace[:0]
+		run(m.MatchMethod(c.method, c.operations...))
+		if !reflect.DeepEqual(trace, c.want) {
+			t.Errorf("%s %v: want %v, got %v", c.method, c.operations, c.want, trace)
+		}
+	}
+}
+
+func TestInvalidSelector(t *testing.T) {
+	if _, err := parseSelector("regex:(["); err == nil {
+		t.Fatal("want error")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want panic")
+		}
+	}()
+	New().Add("regex:([")
+}
+
+func TestRules(t *testing.T) {
+	var trace []string
+	m := New()
+	m.Add("/b", named("b", &trace))
+	m.AddRule(Rule{Selectors: []string{"/a"}, Excludes: []string{"/a/x"}, Priority: -1, Middleware: []transport.Middleware{named("a", &trace)}})
+	if rules := m.Rules(); len(rules) != 2 || rules[0].Selectors[0] != "/a" || rules[0].Excludes[0] != "/a/x" || rules[1].Selectors[0] != "/b" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	m.Use(named("global", &trace))
+	if rules := m.Rules(); len(rules) != 3 || len(rules[0].Selectors) != 0 || len(rules[0].Middleware) != 1 {
+		t.Fatalf("want global rule first, got %+v", rules)
+	}
+}
+
+// TestLegacySelector 兼容旧版本的选择器语义
+func TestLegacySelector(t *testing.T) {
+	var trace []string
+	m := New()
+	m.Add("*/helloworld.Greeter/", named("prefix", &trace))
+	m.Add("", named("empty", &trace))
+	cases := map[string][]string{
+		"/helloworld.Greeter/SayHello": {"prefix"},
+		"/api/helloworld.Greeter/":     nil,
+		"/user.User/Login":             nil,
+	}
+	for operation, want := range cases {
+		trace = trace[:0]
+		run(m.Match(operation))
+		if len(trace) != len(want) || (len(want) > 0 && !reflect.DeepEqual(trace, want)) {
+			t.Errorf("%s: want %v, got %v", operation, want, trace)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket 滑动窗口中的一个桶
+type Bucket struct {
+	Sum   float64 // 桶内数值之和
+	Count int64   // 桶内记录次数
+	Max   float64 // 桶内单次记录的最大值
+	start int64   // 桶起始时间
+}
+
+// Avg 桶内平均值
+func (b Bucket) Avg() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return b.Sum / float64(b.Count)
+}
+
+// Window 基于时间分桶的滑动窗口
+type Window struct {
+	mu       sync.RWMutex
+	buckets  []Bucket
+	interval int64 // 单个桶的时间跨度
+	size     int64
+}
+
+// New 创建滑动窗口，size 为桶数量，duration 为窗口总时长
+func New(size int, duration time.Duration) *Window {
+	if size <= 0 {
+		size = 1
+	}
+	interval := int64(duration) / int64(size)
+	if interval <= 0 {
+		interval = 1
+	}
+	return &Window{
+		buckets:  make([]Bucket, size),
+		interval: interval,
+		size:     int64(size),
+	}
+}
+
+// Interval 单个桶的时间跨度
+func (w *Window) Interval() time.Duration {
+	return time.Duration(w.interval)
+}
+
+// Add 记录一个数值到当前桶
+func (w *Window) Add(v float64) {
+	now := time.Now().UnixNano()
+	start := now - now%w.interval
+	w.mu.Lock()
+	b := &w.buckets[(now/w.interval)%w.size]
+	if b.start != start {
+		*b = Bucket{start: start}
+	}
+	b.Sum += v
+	b.Count++
+	if b.Count == 1 || v > b.Max {
+		b.Max = v
+	}
+	w.mu.Unlock()
+}
+
+// Range 按时间先后遍历窗口内未过期的桶，skipCurrent 为 true 时跳过仍在写入的当前桶
+func (w *Window) Range(skipCurrent bool, fn func(b Bucket) bool) {
+	now := time.Now().UnixNano()
+	current := now - now%w.interval
+	oldest := current - (w.size-1)*w.interval
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for i := int64(0); i < w.size; i++ {
+		start := oldest + i*w.interval
+		if skipCurrent && start == current {
+			continue
+		}
+		b := w.buckets[(start/w.interval)%w.size]
+		if b.start != start {
+			continue
+		}
+		if !fn(b) {
+			return
+		}
+	}
+}
+
+// Summary 窗口内所有数值之和与记录次数
+func (w *Window) Summary() (sum float64, count int64) {
+	w.Range(false, func(b Bucket) bool {
+		sum += b.Sum
+		count += b.Count
+		return true
+	})
+	return
+}
@@ -47,6 +47,7 @@ type Application struct {
 	startupOnce sync.Once              // 启动执行函数
 	stopOnce    sync.Once              // 停止执行函数
 	serviceInfo *transport.ServiceInfo // 服务信息
+	registrar   *registrar             // 服务注册器
 	logger      *logger.Logger         // 日志
 }
 
@@ -65,12 +66,21 @@ func NewWithOptions(opts ...*Options) *Application {
 	if len(opts) > 0 {
 		options = opts[0]
 	}
+	// 同步应用的地域与分区，供同分区优先过滤器等组件使用
+	if options.Region != "" {
+		ceres.SetAppRegion(options.Region)
+	}
+	if options.Zone != "" {
+		ceres.SetAppZone(options.Zone)
+	}
+	log := logger.With(logger.FieldMod(ModName))
 	return &Application{
-		options: options,
-		ctx:     options.ctx,
-		cycle:   cycle.NewCycle(),
-		locker:  &sync.RWMutex{},
-		logger:  logger.With(logger.FieldMod(ModName)),
+		options:   options,
+		ctx:       options.ctx,
+		cycle:     cycle.NewCycle(),
+		locker:    &sync.RWMutex{},
+		registrar: newRegistrar(options, log),
+		logger:    log,
 	}
 }
 
@@ -198,10 +208,8 @@ func (app *Application) startServers() error {
 	wg.Wait()
 
 	// 注册服务
-	if app.options.registry != nil {
-		ctx, cancel := context.WithTimeout(appCtx, 3*time.Second)
-		defer cancel()
-		if err = app.options.registry.Register(ctx, info); err != nil {
+	if len(app.options.registries) > 0 {
+		if err = app.registrar.register(appCtx, info); err != nil {
 			return err
 		}
 	}
@@ -248,16 +256,20 @@ func (app *Application) Stop() (err error) {
 		// 执行钩子
 		app.runHook(BeforeStop)
 		// 服务信息
+		app.locker.RLock()
 		serverInfo := app.serviceInfo
+		app.locker.RUnlock()
 		// 注销服务
-		stopCtx, cancel := context.WithTimeout(NewContext(app.ctx, app), app.options.StopTimeout)
-		defer cancel()
-		if app.options.registry != nil && serverInfo != nil {
-			if err = app.options.registry.Deregister(stopCtx, serverInfo); err != nil {
-				app.logger.Error("stop server error", logger.FieldError(err))
-			}
+		if len(app.options.registries) > 0 && serverInfo != nil {
+			err = app.registrar.deregister(NewContext(app.ctx, app), serverInfo)
+		} else {
+			app.registrar.stop()
 		}
+		// 等待处理中的请求完成
+		app.drain()
 		// 停止服务
+		stopCtx, cancel := context.WithTimeout(NewContext(app.ctx, app), app.options.StopTimeout)
+		defer cancel()
 		app.locker.RLock()
 		for _, s := range app.options.transports {
 			s := s
@@ -273,6 +285,32 @@ func (app *Application) Stop() (err error) {
 	return err
 }
 
+// drain 等待服务处理中的请求完成，最长等待 DrainTimeout
+func (app *Application) drain() {
+	if app.options.DrainTimeout <= 0 {
+		return
+	}
+	deadline := time.Now().Add(app.options.DrainTimeout)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var inflight int64
+		for _, srv := range app.options.transports {
+			if d, ok := srv.(transport.Drainer); ok {
+				inflight += d.Inflight()
+			}
+		}
+		if inflight == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			app.logger.Warnf("drain timeout, %d requests still in flight", inflight)
+			return
+		}
+		<-ticker.C
+	}
+}
+
 // clear 清除
 func (app *Application) clear() {
 	_ = logger.GetLogger().Sync()
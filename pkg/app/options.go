@@ -43,38 +43,48 @@ type Option func(o *Options)
 
 // Options 配置信息
 type Options struct {
-	ctx              context.Context         // 应用上下文
-	ID               string                  `json:"id"`               // 应用唯一标识
-	Name             string                  `json:"name"`             // 应用名称
-	Version          string                  `json:"version"`          // 应用版本
-	Metadata         map[string]string       `json:"metadata"`         // 附加信息
-	Endpoints        []*url.URL              `json:"endpoints"`        // 服务地址
-	Region           string                  `json:"region"`           // 服务所属地域
-	Zone             string                  `json:"zone"`             // 服务所属分区
-	HideBanner       bool                    `json:"hideBanner"`       // 隐藏打印横幅
-	MaxProc          int64                   `json:"maxProc"`          // 处理器内核优化
-	RegistrarTimeout time.Duration           `json:"registrarTimeout"` // 服务注册超时时间
-	StopTimeout      time.Duration           `json:"stopTimeout"`      // 注销服务超时时间
-	hooks            map[HookType][]HookFunc // 启动钩子
-	transports       []transport.Transport   // 服务集合
-	registry         transport.Registry      // 注册中心
-	logger           logger.Logger           // 日志组件
+	ctx                     context.Context         // 应用上下文
+	ID                      string                  `json:"id"`                      // 应用唯一标识
+	Name                    string                  `json:"name"`                    // 应用名称
+	Version                 string                  `json:"version"`                 // 应用版本
+	Metadata                map[string]string       `json:"metadata"`                // 附加信息
+	Endpoints               []*url.URL              `json:"endpoints"`               // 服务地址
+	Region                  string                  `json:"region"`                  // 服务所属地域
+	Zone                    string                  `json:"zone"`                    // 服务所属分区
+	HideBanner              bool                    `json:"hideBanner"`              // 隐藏打印横幅
+	MaxProc                 int64                   `json:"maxProc"`                 // 处理器内核优化
+	RegistrarTimeout        time.Duration           `json:"registrarTimeout"`        // 单次服务注册与注销的超时时间
+	RegistrarMaxRetry       int                     `json:"registrarMaxRetry"`       // 启动时注册失败的最大重试次数，小于0时一直重试
+	RegistrarInitialBackoff time.Duration           `json:"registrarInitialBackoff"` // 注册失败首次重试的退避时间
+	RegistrarMaxBackoff     time.Duration           `json:"registrarMaxBackoff"`     // 注册失败重试的最大退避时间
+	RegistrarInterval       time.Duration           `json:"registrarInterval"`       // 定时重新注册的间隔，小于等于0时关闭
+	DrainTimeout            time.Duration           `json:"drainTimeout"`            // 注销服务后等待处理中请求完成的最长时间
+	StopTimeout             time.Duration           `json:"stopTimeout"`             // 停止服务超时时间
+	hooks                   map[HookType][]HookFunc // 启动钩子
+	transports              []transport.Transport   // 服务集合
+	registries              []transport.Registry    // 注册中心
+	logger                  logger.Logger           // 日志组件
 }
 
 // DefaultOptions 默认配置
 func DefaultOptions() *Options {
 	return &Options{
-		ctx:              context.Background(),
-		ID:               ceres.AppId(),
-		Name:             ceres.AppName(),
-		Version:          ceres.AppVersion(),
-		Region:           ceres.AppRegion(),
-		Zone:             ceres.AppZone(),
-		HideBanner:       false,
-		RegistrarTimeout: 10 * time.Second,
-		StopTimeout:      3 * time.Second,
-		hooks:            map[HookType][]HookFunc{},
-		transports:       []transport.Transport{},
+		ctx:                     context.Background(),
+		ID:                      ceres.AppId(),
+		Name:                    ceres.AppName(),
+		Version:                 ceres.AppVersion(),
+		Region:                  ceres.AppRegion(),
+		Zone:                    ceres.AppZone(),
+		HideBanner:              false,
+		RegistrarTimeout:        10 * time.Second,
+		RegistrarMaxRetry:       5,
+		RegistrarInitialBackoff: time.Second,
+		RegistrarMaxBackoff:     30 * time.Second,
+		RegistrarInterval:       30 * time.Second,
+		DrainTimeout:            5 * time.Second,
+		StopTimeout:             3 * time.Second,
+		hooks:                   map[HookType][]HookFunc{},
+		transports:              []transport.Transport{},
 	}
 }
 
@@ -159,6 +169,29 @@ func WithRegistrarTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithRegistrarRetry 设置启动时注册失败的最大重试次数与退避时间
+func WithRegistrarRetry(maxRetry int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(o *Options) {
+		o.RegistrarMaxRetry = maxRetry
+		o.RegistrarInitialBackoff = initialBackoff
+		o.RegistrarMaxBackoff = maxBackoff
+	}
+}
+
+// WithRegistrarInterval 设置定时重新注册的间隔
+func WithRegistrarInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RegistrarInterval = interval
+	}
+}
+
+// WithDrainTimeout 设置等待处理中请求完成的最长时间
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.DrainTimeout = timeout
+	}
+}
+
 // WithStopTimeout 设置停止服务超时时间
 func WithStopTimeout(timeout time.Duration) Option {
 	return func(o *Options) {
@@ -186,10 +219,17 @@ func AddHooks(hookType HookType, hooks ...HookFunc) Option {
 	}
 }
 
-// WithRegistry 设置注册中心
-func WithRegistry(reg transport.Registry) Option {
+// WithRegistry 设置注册中心，可同时注册到多个注册中心
+func WithRegistry(registries ...transport.Registry) Option {
+	return func(o *Options) {
+		o.registries = registries
+	}
+}
+
+// AddRegistry 添加注册中心
+func AddRegistry(registries ...transport.Registry) Option {
 	return func(o *Options) {
-		o.registry = reg
+		o.registries = append(o.registries, registries...)
 	}
 }
 
@@ -0,0 +1,209 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	ic "github.com/go-ceres/ceres/internal/context"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+	"time"
+)
+
+// registrar 服务注册器，负责向多个注册中心注册服务，失败时退避重试，并定时重新注册
+type registrar struct {
+	opts    *Options
+	logger  *logger.Logger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex // 保护 stopped 与 wg.Add
+	stopped bool       // 是否已停止，停止后不再启动定时注册
+}
+
+func newRegistrar(opts *Options, log *logger.Logger) *registrar {
+	r := &registrar{
+		opts:   opts,
+		logger: log,
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	return r
+}
+
+// register 并发注册到全部注册中心，任一注册中心重试后仍失败时返回错误，成功后开始定时重新注册
+func (r *registrar) register(ctx context.Context, info *transport.ServiceInfo) error {
+	ctx, cancel := ic.Merge(ctx, r.ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, registry := range r.opts.registries {
+		registry := registry
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.registerWithRetry(ctx, registry, info); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		// 注册期间应用已停止，注销可能在注册完成前执行，这里再注销一次
+		_ = r.deregisterAll(context.Background(), info)
+		return nil
+	}
+	if r.opts.RegistrarInterval > 0 {
+		for _, registry := range r.opts.registries {
+			registry := registry
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				r.keepalive(registry, info)
+			}()
+		}
+	}
+	return nil
+}
+
+// registerWithRetry 注册服务，失败时按退避时间重试
+func (r *registrar) registerWithRetry(ctx context.Context, registry transport.Registry, info *transport.ServiceInfo) error {
+	for attempt := 0; ; attempt++ {
+		err := r.registerOnce(ctx, registry, info)
+		if err == nil {
+			return nil
+		}
+		if r.opts.RegistrarMaxRetry >= 0 && attempt >= r.opts.RegistrarMaxRetry {
+			return err
+		}
+		backoff := r.backoff(attempt)
+		r.logger.Warnf("register service failed, retry after %s: %v", backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// registerOnce 注册一次服务
+func (r *registrar) registerOnce(ctx context.Context, registry transport.Registry, info *transport.ServiceInfo) error {
+	if r.opts.RegistrarTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.RegistrarTimeout)
+		defer cancel()
+	}
+	return registry.Register(ctx, info)
+}
+
+// keepalive 定时重新注册，防止注册中心故障恢复后丢失服务，失败时按退避时间重试
+func (r *registrar) keepalive(registry transport.Registry, info *transport.ServiceInfo) {
+	failures := 0
+	timer := time.NewTimer(r.opts.RegistrarInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if err := r.registerOnce(r.ctx, registry, info); err != nil {
+			if r.ctx.Err() != nil {
+				return
+			}
+			backoff := r.backoff(failures)
+			failures++
+			r.logger.Warnf("re-register service failed, retry after %s: %v", backoff, err)
+			timer.Reset(backoff)
+			continue
+		}
+		failures = 0
+		timer.Reset(r.opts.RegistrarInterval)
+	}
+}
+
+// stop 停止注册，停止后完成的注册会被立即注销
+func (r *registrar) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.cancel()
+}
+
+// deregister 停止定时注册，并发从全部注册中心注销服务，注销失败时记录日志并返回第一个错误
+func (r *registrar) deregister(ctx context.Context, info *transport.ServiceInfo) error {
+	r.stop()
+	r.wg.Wait()
+	return r.deregisterAll(ctx, info)
+}
+
+// deregisterAll 并发从全部注册中心注销服务
+func (r *registrar) deregisterAll(ctx context.Context, info *transport.ServiceInfo) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, registry := range r.opts.registries {
+		registry := registry
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := ctx
+			if r.opts.RegistrarTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, r.opts.RegistrarTimeout)
+				defer cancel()
+			}
+			if err := registry.Deregister(ctx, info); err != nil {
+				r.logger.Error("deregister service error", logger.FieldError(err))
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// backoff 指数退避时间
+func (r *registrar) backoff(attempt int) time.Duration {
+	d := r.opts.RegistrarInitialBackoff
+	for i := 0; i < attempt && (r.opts.RegistrarMaxBackoff <= 0 || d < r.opts.RegistrarMaxBackoff); i++ {
+		d *= 2
+	}
+	if r.opts.RegistrarMaxBackoff > 0 && d > r.opts.RegistrarMaxBackoff {
+		d = r.opts.RegistrarMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,211 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeRegistry struct {
+	mu           sync.Mutex
+	failures     int
+	registers    int
+	deregisters  int
+	deregistered bool
+	delay        time.Duration // 注册耗时，模拟注册完成前应用已停止
+}
+
+func (f *fakeRegistry) Register(_ context.Context, _ *transport.ServiceInfo) error {
+	time.Sleep(f.delay)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.registers++
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("registry unavailable")
+	}
+	return nil
+}
+
+func (f *fakeRegistry) Deregister(_ context.Context, _ *transport.ServiceInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deregistered = true
+	f.deregisters++
+	return nil
+}
+
+func (f *fakeRegistry) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.registers
+}
+
+func newTestRegistrar(opts ...Option) *registrar {
+	options := DefaultOptions().WithOption(opts...)
+	return newRegistrar(options, logger.With(logger.FieldMod(ModName)))
+}
+
+func TestRegistrarRetry(t *testing.T) {
+	flaky, healthy := &fakeRegistry{failures: 2}, &fakeRegistry{}
+	r := newTestRegistrar(
+		WithRegistry(flaky, healthy),
+		WithRegistrarRetry(3, time.Millisecond, 5*time.Millisecond),
+		WithRegistrarInterval(0),
+	)
+	info := &transport.ServiceInfo{ID: "1", Name: "greeter"}
+	if err := r.register(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if flaky.count() != 3 || healthy.count() != 1 {
+		t.Fatalf("unexpected register attempts %d %d", flaky.count(), healthy.count())
+	}
+	if err := r.deregister(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if !flaky.deregistered || !healthy.deregistered {
+		t.Fatal("all registries must be deregistered")
+	}
+
+	r = newTestRegistrar(
+		WithRegistry(&fakeRegistry{failures: 10}),
+		WithRegistrarRetry(2, time.Millisecond, 5*time.Millisecond),
+	)
+	if err := r.register(context.Background(), info); err == nil {
+		t.Fatal("want error after retries exhausted")
+	}
+}
+
+func TestRegistrarKeepalive(t *testing.T) {
+	reg := &fakeRegistry{}
+	r := newTestRegistrar(
+		WithRegistry(reg),
+		WithRegistrarInterval(10*time.Millisecond),
+	)
+	info := &transport.ServiceInfo{ID: "1", Name: "greeter"}
+	if err := r.register(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if reg.count() < 3 {
+		t.Fatalf("want periodic re-registration, got %d", reg.count())
+	}
+	_ = r.deregister(context.Background(), info)
+	count := reg.count()
+	time.Sleep(50 * time.Millisecond)
+	if reg.count() != count {
+		t.Fatal("re-registration must stop after deregister")
+	}
+}
+
+func TestRegistrarStopDuringRegister(t *testing.T) {
+	reg := &fakeRegistry{delay: 100 * time.Millisecond}
+	r := newTestRegistrar(
+		WithRegistry(reg),
+		WithRegistrarInterval(10*time.Millisecond),
+	)
+	info := &transport.ServiceInfo{ID: "1", Name: "greeter"}
+	done := make(chan error, 1)
+	go func() {
+		done <- r.register(context.Background(), info)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := r.deregister(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	// 注册在注销之后完成，需要再注销一次，且不再定时注册
+	if reg.registers != 1 || reg.deregisters != 2 {
+		t.Fatalf("want 1 register and 2 deregisters, got %d %d", reg.registers, reg.deregisters)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	r := newTestRegistrar(WithRegistrarRetry(5, time.Second, 5*time.Second))
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range want {
+		if got := r.backoff(i); got != d {
+			t.Fatalf("attempt %d: want %s, got %s", i, d, got)
+		}
+	}
+}
+
+type fakeTransport struct {
+	inflight int64
+	stopped  bool
+}
+
+func (f *fakeTransport) Kind() transport.Kind { return "fake" }
+
+func (f *fakeTransport) Start(_ context.Context) error { return nil }
+
+func (f *fakeTransport) Stop(_ context.Context) error {
+	f.stopped = true
+	return nil
+}
+
+func (f *fakeTransport) Inflight() int64 { return atomic.LoadInt64(&f.inflight) }
+
+func TestDrain(t *testing.T) {
+	srv := &fakeTransport{inflight: 1}
+	app := New(WithTransport(srv), WithDrainTimeout(time.Second))
+	time.AfterFunc(100*time.Millisecond, func() {
+		atomic.StoreInt64(&srv.inflight, 0)
+	})
+	start := time.Now()
+	app.drain()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("drain should wait for in-flight requests, took %v", elapsed)
+	}
+
+	// 超时后不再等待
+	atomic.StoreInt64(&srv.inflight, 1)
+	app = New(WithTransport(srv), WithDrainTimeout(100*time.Millisecond))
+	start = time.Now()
+	app.drain()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("drain should stop after timeout, took %v", elapsed)
+	}
+}
+
+func TestStopDrainWithoutRegistry(t *testing.T) {
+	srv := &fakeTransport{inflight: 1}
+	app := New(WithTransport(srv), WithDrainTimeout(time.Second))
+	time.AfterFunc(100*time.Millisecond, func() {
+		atomic.StoreInt64(&srv.inflight, 0)
+	})
+	start := time.Now()
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("stop without registry should drain, took %v", elapsed)
+	}
+	if !srv.stopped {
+		t.Fatal("transport should be stopped")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+)
+
+// Cmder redis命令
+type Cmder = redis.Cmder
+
+// Hook 命令执行钩子，next 为后续处理流程，可用于链路追踪、指标统计等
+type Hook func(ctx context.Context, cmd Cmder, next func(cmd Cmder) error) error
+
+// wrapHooks 将钩子按顺序包装到命令处理流程中
+func wrapHooks(ctx context.Context, hooks []Hook) func(old func(cmd Cmder) error) func(cmd Cmder) error {
+	return func(old func(cmd Cmder) error) func(cmd Cmder) error {
+		next := old
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hook, n := hooks[i], next
+			next = func(cmd Cmder) error {
+				return hook(ctx, cmd, n)
+			}
+		}
+		return next
+	}
+}
+
+// Nil 键不存在时返回的错误
+const Nil = redis.Nil
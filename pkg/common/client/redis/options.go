@@ -55,6 +55,7 @@ type Options struct {
 	OnConnect          func(*redis.Conn) error
 	TLSConfig          *tls.Config
 	logger             *logger.Logger // 日志组件
+	hooks              []Hook         // 命令执行钩子
 }
 
 func WithAddrs(addrs []string) Option {
@@ -179,6 +180,13 @@ func WithLogger(logger *logger.Logger) Option {
 	}
 }
 
+// WithHooks 添加命令执行钩子
+func WithHooks(hooks ...Hook) Option {
+	return func(o *Options) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
 // DefaultOptions 默认配置参数
 func DefaultOptions() *Options {
 	return &Options{
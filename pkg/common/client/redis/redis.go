@@ -16,12 +16,15 @@
 package redis
 
 import (
+	"context"
 	"github.com/go-redis/redis"
 	"time"
 )
 
 type Client struct {
 	options *Options
+	ctx     context.Context
+	raw     redis.UniversalClient // 未包装钩子的原始客户端
 	client  redis.UniversalClient
 }
 
@@ -60,10 +63,42 @@ func NewWithOptions(options *Options) *Client {
 	if err := cli.Ping().Err(); err != nil {
 		panic(err)
 	}
-	return &Client{
-		client:  cli,
+	c := &Client{
+		raw:     cli,
 		options: options,
 	}
+	return c.WithContext(context.Background())
+}
+
+// WithContext 返回绑定了上下文的客户端，命令执行钩子将收到该上下文
+func (r *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *r
+	c.ctx = ctx
+	switch cli := r.raw.(type) {
+	case *redis.Client:
+		c.client = cli.WithContext(ctx)
+	case *redis.ClusterClient:
+		c.client = cli.WithContext(ctx)
+	default:
+		// 无法克隆的客户端不支持钩子，避免重复包装
+		c.client = cli
+		return &c
+	}
+	if len(r.options.hooks) > 0 {
+		c.client.WrapProcess(wrapHooks(ctx, r.options.hooks))
+	}
+	return &c
+}
+
+// Context 获取客户端绑定的上下文
+func (r *Client) Context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
 }
 
 // Keys 查询指定前缀的key
@@ -107,6 +107,16 @@ func IsRequestEntityTooLarge(err error) bool {
 	return Code(err) == 413
 }
 
+// TooManyRequests 请求过多，对应http的429
+func TooManyRequests(reason, message string) *Error {
+	return New(429, reason, message)
+}
+
+// IsTooManyRequests 判断是否是请求过多
+func IsTooManyRequests(err error) bool {
+	return Code(err) == 429
+}
+
 // InternalServer 内部服务器错误
 func InternalServer(reason, message string) *Error {
 	return New(500, reason, message)
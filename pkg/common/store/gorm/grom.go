@@ -48,6 +48,12 @@ func NewWithOptions(options *Options) *DB {
 	if err != nil {
 		panic(err)
 	}
+	// 注册插件
+	for _, plugin := range options.plugins {
+		if err := inner.Use(plugin); err != nil {
+			panic(err)
+		}
+	}
 	sqlDb, err := inner.DB()
 	if err != nil {
 		panic(err)
@@ -135,25 +135,33 @@ func (l slog) Trace(ctx context.Context, begin time.Time, fc func() (string, int
 		case err != nil && l.level >= Level(log.Error):
 			sql, rows := fc()
 			if rows == -1 {
-				l.logger.Errorf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+				l.logger.Errorf(l.traceErrStr, withTraceID(ctx, utils.FileWithLineNum()), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
 			} else {
-				l.logger.Errorf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+				l.logger.Errorf(l.traceErrStr, withTraceID(ctx, utils.FileWithLineNum()), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 			}
 		case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.level >= Level(log.Warn):
 			sql, rows := fc()
 			slowLog := fmt.Sprintf("SLOW SQL >= %v", l.SlowThreshold)
 			if rows == -1 {
-				l.logger.Warnf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+				l.logger.Warnf(l.traceWarnStr, withTraceID(ctx, utils.FileWithLineNum()), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
 			} else {
-				l.logger.Warnf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+				l.logger.Warnf(l.traceWarnStr, withTraceID(ctx, utils.FileWithLineNum()), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 			}
 		case l.level >= Level(log.Info):
 			sql, rows := fc()
 			if rows == -1 {
-				l.logger.Infof(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
+				l.logger.Infof(l.traceStr, withTraceID(ctx, utils.FileWithLineNum()), float64(elapsed.Nanoseconds())/1e6, "-", sql)
 			} else {
-				l.logger.Infof(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, rows, sql)
+				l.logger.Infof(l.traceStr, withTraceID(ctx, utils.FileWithLineNum()), float64(elapsed.Nanoseconds())/1e6, rows, sql)
 			}
 		}
 	}
 }
+
+// withTraceID 上下文中存在追踪ID时附加到调用位置后输出
+func withTraceID(ctx context.Context, fileWithLine string) string {
+	if traceID, ok := FromTraceID(ctx); ok {
+		return fileWithLine + " trace_id=" + traceID
+	}
+	return fileWithLine
+}
@@ -37,6 +37,7 @@ type Options struct {
 	gormConfig      *gorm.Config   // gorm配置
 	dialect         Dialector      // 驱动连接器
 	logger          *logger.Logger // 日志库
+	plugins         []gorm.Plugin  // gorm插件
 	// 下面配置来自于gorm的配置，详情可查看gorm官方文档
 	SkipDefaultTransaction                   bool `json:"skipDefaultTransaction"`
 	FullSaveAssociations                     bool `json:"fullSaveAssociations"`
@@ -151,6 +152,13 @@ func WithLogger(log *logger.Logger) Option {
 	}
 }
 
+// WithPlugins 添加gorm插件，如链路追踪插件
+func WithPlugins(plugins ...gorm.Plugin) Option {
+	return func(o *Options) {
+		o.plugins = append(o.plugins, plugins...)
+	}
+}
+
 // DefaultOptions 默认参数
 func DefaultOptions() *Options {
 	return &Options{
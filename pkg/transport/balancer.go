@@ -24,11 +24,32 @@ import (
 )
 
 var (
-	forcePick                 = time.Second * 3
-	_         Balancer        = (*DefaultBalancer)(nil)
-	_         BalancerBuilder = (*DefaultBalancerBuilder)(nil)
+	forcePick                   = time.Second * 3
+	_           Balancer        = (*DefaultBalancer)(nil)
+	_           BalancerBuilder = (*DefaultBalancerBuilder)(nil)
+	balancers                   = map[string]BalancerBuilder{}
+	balancersMu sync.RWMutex
 )
 
+func init() {
+	RegisterBalancerBuilder(selectorName, &DefaultBalancerBuilder{})
+}
+
+// RegisterBalancerBuilder 按名称注册负载均衡器构建器，应在 init 中调用
+func RegisterBalancerBuilder(name string, builder BalancerBuilder) {
+	balancersMu.Lock()
+	defer balancersMu.Unlock()
+	balancers[name] = builder
+}
+
+// GetBalancerBuilder 根据名称获取负载均衡器构建器
+func GetBalancerBuilder(name string) (BalancerBuilder, bool) {
+	balancersMu.RLock()
+	defer balancersMu.RUnlock()
+	builder, ok := balancers[name]
+	return builder, ok
+}
+
 // BalancerBuilder 负载均衡器构建接口
 type BalancerBuilder interface {
 	Build() Balancer
@@ -39,6 +60,12 @@ type Balancer interface {
 	Pick(ctx context.Context, nodes []IWeightedNode) (selected IWeightedNode, done DoneFunc, err error)
 }
 
+// BalancerUpdater 需要感知全部节点的负载均衡器，选择器存储节点时调用，
+// 如一致性哈希负载均衡器据此构建查找表，选择时跳过被过滤的节点
+type BalancerUpdater interface {
+	Update(nodes []IWeightedNode)
+}
+
 // DefaultBalancerBuilder 默认实现
 type DefaultBalancerBuilder struct{}
 
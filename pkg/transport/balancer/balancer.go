@@ -0,0 +1,99 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+const (
+	// RoundRobin 轮询
+	RoundRobin = "round_robin"
+	// WeightedRoundRobin 平滑加权轮询
+	WeightedRoundRobin = "weighted_round_robin"
+	// Random 随机
+	Random = "random"
+	// LeastRequest 最少请求
+	LeastRequest = "least_request"
+	// RingHash 一致性哈希环
+	RingHash = "ring_hash"
+	// Maglev Maglev一致性哈希
+	Maglev = "maglev"
+)
+
+// defaultWeight 节点未设置初始权重时使用的权重
+const defaultWeight int64 = 100
+
+func init() {
+	transport.RegisterBalancerBuilder(RoundRobin, &RoundRobinBuilder{})
+	transport.RegisterBalancerBuilder(WeightedRoundRobin, &WeightedRoundRobinBuilder{})
+	transport.RegisterBalancerBuilder(Random, &RandomBuilder{})
+	transport.RegisterBalancerBuilder(LeastRequest, &LeastRequestBuilder{})
+	transport.RegisterBalancerBuilder(RingHash, &RingHashBuilder{})
+	transport.RegisterBalancerBuilder(Maglev, &MaglevBuilder{})
+}
+
+type hashKey struct{}
+
+// NewHashKeyContext 设置一致性哈希的键，如用户ID、会话ID
+func NewHashKeyContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+// HashKeyFromContext 获取一致性哈希的键
+func HashKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(hashKey{}).(string)
+	return key, ok
+}
+
+// nodeWeight 获取节点的初始权重
+func nodeWeight(node transport.Node) int64 {
+	if w := node.InitialWeight(); w != nil && *w > 0 {
+		return *w
+	}
+	return defaultWeight
+}
+
+// nodeSet 构建哈希表使用的节点集合，表项为节点在集合中的下标
+type nodeSet struct {
+	nodes []transport.IWeightedNode
+	index map[transport.IWeightedNode]int
+}
+
+func newNodeSet(nodes []transport.IWeightedNode) *nodeSet {
+	s := &nodeSet{
+		nodes: append(nodes[:0:0], nodes...),
+		index: make(map[transport.IWeightedNode]int, len(nodes)),
+	}
+	for i, n := range nodes {
+		s.index[n] = i
+	}
+	return s
+}
+
+// candidates 按集合下标排列候选节点，不在候选中的位置为空，候选节点不全在集合中时返回false
+func (s *nodeSet) candidates(nodes []transport.IWeightedNode) ([]transport.IWeightedNode, bool) {
+	res := make([]transport.IWeightedNode, len(s.nodes))
+	for _, n := range nodes {
+		i, ok := s.index[n]
+		if !ok {
+			return nil, false
+		}
+		res[i] = n
+	}
+	return res, true
+}
@@ -0,0 +1,188 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"strconv"
+	"testing"
+)
+
+func newNodes(weights ...int64) []transport.IWeightedNode {
+	builder := &transport.DefaultWeightNodeBuilder{}
+	nodes := make([]transport.IWeightedNode, 0, len(weights))
+	for i, w := range weights {
+		info := &transport.ServiceInfo{
+			Name:     "test",
+			Metadata: map[string]string{"weight": strconv.FormatInt(w, 10)},
+		}
+		nodes = append(nodes, builder.Build(transport.NewNode("grpc", fmt.Sprintf("127.0.0.%d:9000", i+1), info)))
+	}
+	return nodes
+}
+
+func pickN(t *testing.T, b transport.Balancer, ctx context.Context, nodes []transport.IWeightedNode, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		node, done, err := b.Pick(ctx, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(ctx, transport.DoneInfo{})
+		counts[node.Address()]++
+	}
+	return counts
+}
+
+func TestRegistered(t *testing.T) {
+	for _, name := range []string{RoundRobin, WeightedRoundRobin, Random, LeastRequest, RingHash, Maglev} {
+		if _, ok := transport.GetBalancerBuilder(name); !ok {
+			t.Fatalf("balancer %s not registered", name)
+		}
+		if _, err := transport.BuildSelector(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	nodes := newNodes(1, 1, 1)
+	counts := pickN(t, (&RoundRobinBuilder{}).Build(), context.Background(), nodes, 300)
+	for _, n := range nodes {
+		if counts[n.Address()] != 100 {
+			t.Fatalf("unexpected distribution %v", counts)
+		}
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	nodes := newNodes(5, 1, 1)
+	b := (&WeightedRoundRobinBuilder{}).Build()
+	// 平滑加权轮询的一个周期: a a b a c a a
+	var seq string
+	for i := 0; i < 7; i++ {
+		node, _, _ := b.Pick(context.Background(), nodes)
+		seq += node.Address()[8:9]
+	}
+	if seq != "1121311" {
+		t.Fatalf("unexpected sequence %s", seq)
+	}
+	counts := pickN(t, b, context.Background(), nodes, 700)
+	if counts[nodes[0].Address()] != 500 || counts[nodes[1].Address()] != 100 {
+		t.Fatalf("unexpected distribution %v", counts)
+	}
+}
+
+func TestLeastRequest(t *testing.T) {
+	nodes := newNodes(1, 1)
+	// 第一个节点保持一个处理中的请求
+	nodes[0].Pick()
+	b := (&LeastRequestBuilder{}).Build()
+	for i := 0; i < 10; i++ {
+		node, done, _ := b.Pick(context.Background(), nodes)
+		if node != nodes[1] {
+			t.Fatalf("want least loaded node, got %s", node.Address())
+		}
+		done(context.Background(), transport.DoneInfo{})
+	}
+}
+
+func testConsistentHash(t *testing.T, builder transport.BalancerBuilder) {
+	nodes := newNodes(100, 100, 100, 100)
+	b := builder.Build()
+	picked := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		ctx := NewHashKeyContext(context.Background(), key)
+		node, _, err := b.Pick(ctx, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _, _ := b.Pick(ctx, nodes)
+		if again != node {
+			t.Fatalf("key %s picked different nodes", key)
+		}
+		picked[key] = node.Address()
+		counts[node.Address()]++
+	}
+	for _, n := range nodes {
+		if c := counts[n.Address()]; c < 150 || c > 350 {
+			t.Fatalf("unbalanced distribution %v", counts)
+		}
+	}
+	// 移除一个节点后，其他节点上的键不应迁移
+	removed := nodes[3].Address()
+	remain := nodes[:3]
+	for key, addr := range picked {
+		if addr == removed {
+			continue
+		}
+		node, _, _ := b.Pick(NewHashKeyContext(context.Background(), key), remain)
+		if node.Address() != addr {
+			t.Fatalf("key %s moved from %s to %s", key, addr, node.Address())
+		}
+	}
+}
+
+func TestRingHash(t *testing.T) {
+	testConsistentHash(t, &RingHashBuilder{})
+}
+
+func TestMaglev(t *testing.T) {
+	testConsistentHash(t, &MaglevBuilder{})
+}
+
+func TestMaglevWeight(t *testing.T) {
+	nodes := newNodes(300, 100)
+	table := buildMaglevTable(nodes, 65537)
+	counts := make([]int, len(nodes))
+	for _, i := range table {
+		counts[i]++
+	}
+	ratio := float64(counts[0]) / float64(counts[1])
+	if ratio < 2.8 || ratio > 3.2 {
+		t.Fatalf("unexpected weight ratio %f", ratio)
+	}
+}
+
+// TestHashTableReuse 过滤后的节点复用全部节点构建的哈希表
+func TestHashTableReuse(t *testing.T) {
+	nodes := newNodes(100, 100, 100, 100)
+	m := (&MaglevBuilder{}).Build().(*maglev)
+	r := (&RingHashBuilder{}).Build().(*ringHash)
+	m.Update(nodes)
+	r.Update(nodes)
+	table, ring := m.table, r.ring
+	for i := 0; i < 100; i++ {
+		ctx := NewHashKeyContext(context.Background(), "user-"+strconv.Itoa(i))
+		filtered := append(nodes[:i%4:i%4], nodes[i%4+1:]...)
+		for _, b := range []transport.Balancer{m, r} {
+			node, _, err := b.Pick(ctx, filtered)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node == nodes[i%4] {
+				t.Fatalf("filtered node %s was picked", node.Address())
+			}
+		}
+	}
+	if m.table != table || r.ring != ring {
+		t.Fatal("hash table should not be rebuilt for filtered nodes")
+	}
+}
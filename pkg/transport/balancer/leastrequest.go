@@ -0,0 +1,64 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+var _ transport.Balancer = (*leastRequest)(nil)
+
+// LeastRequestBuilder 最少请求负载均衡器构建器，随机选出两个节点后选择处理中请求较少的节点
+type LeastRequestBuilder struct{}
+
+// Build 构建最少请求负载均衡器
+func (b *LeastRequestBuilder) Build() transport.Balancer {
+	return &leastRequest{
+		random: (&RandomBuilder{}).Build().(*random),
+	}
+}
+
+type leastRequest struct {
+	*random
+}
+
+func (l *leastRequest) Pick(_ context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nodes[0].Pick(), nil
+	}
+	a := l.intn(len(nodes))
+	b := l.intn(len(nodes) - 1)
+	if b >= a {
+		b++
+	}
+	selected := nodes[a]
+	if inflight(nodes[b]) < inflight(selected) {
+		selected = nodes[b]
+	}
+	return selected, selected.Pick(), nil
+}
+
+// inflight 节点处理中的请求数，节点未提供统计信息时视为0
+func inflight(node transport.IWeightedNode) int64 {
+	if stats, ok := node.(transport.NodeStats); ok {
+		return stats.Inflight()
+	}
+	return 0
+}
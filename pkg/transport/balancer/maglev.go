@@ -0,0 +1,156 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+)
+
+var _ transport.Balancer = (*maglev)(nil)
+
+// defaultMaglevTableSize Maglev查找表默认长度，需为质数
+const defaultMaglevTableSize = 65537
+
+// MaglevBuilder Maglev一致性哈希负载均衡器构建器，使用 NewHashKeyContext 设置的键选择节点，
+// 节点在查找表中的占比与初始权重成正比，上下文中没有键时随机选择
+type MaglevBuilder struct {
+	TableSize uint64 // 查找表长度，需为质数，默认65537
+}
+
+// Build 构建Maglev负载均衡器
+func (b *MaglevBuilder) Build() transport.Balancer {
+	size := b.TableSize
+	if size == 0 {
+		size = defaultMaglevTableSize
+	}
+	return &maglev{
+		tableSize: size,
+		random:    (&RandomBuilder{}).Build().(*random),
+	}
+}
+
+// maglevTable 节点集合对应的查找表
+type maglevTable struct {
+	*nodeSet
+	table []int
+}
+
+type maglev struct {
+	tableSize uint64
+	random    *random
+	mu        sync.RWMutex
+	table     *maglevTable
+}
+
+// Update 使用全部节点构建查找表，过滤后的节点复用该表
+func (m *maglev) Update(nodes []transport.IWeightedNode) {
+	var table *maglevTable
+	if len(nodes) > 0 {
+		table = &maglevTable{nodeSet: newNodeSet(nodes), table: buildMaglevTable(nodes, m.tableSize)}
+	}
+	m.mu.Lock()
+	m.table = table
+	m.mu.Unlock()
+}
+
+func (m *maglev) Pick(ctx context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	key, ok := HashKeyFromContext(ctx)
+	if !ok {
+		return m.random.Pick(ctx, nodes)
+	}
+	table, candidates := m.getTable(nodes)
+	// 从键对应的表项开始，跳过被过滤的节点
+	size := uint64(len(table.table))
+	pos := hash64(key) % size
+	for i := uint64(0); i < size; i++ {
+		if n := candidates[table.table[(pos+i)%size]]; n != nil {
+			return n, n.Pick(), nil
+		}
+	}
+	return m.random.Pick(ctx, nodes)
+}
+
+// getTable 获取包含全部候选节点的查找表，存在表中没有的节点时按候选节点重新构建
+func (m *maglev) getTable(nodes []transport.IWeightedNode) (*maglevTable, []transport.IWeightedNode) {
+	m.mu.RLock()
+	table := m.table
+	m.mu.RUnlock()
+	if table != nil {
+		if candidates, ok := table.candidates(nodes); ok {
+			return table, candidates
+		}
+	}
+	table = &maglevTable{nodeSet: newNodeSet(nodes), table: buildMaglevTable(nodes, m.tableSize)}
+	m.mu.Lock()
+	m.table = table
+	m.mu.Unlock()
+	candidates, _ := table.candidates(nodes)
+	return table, candidates
+}
+
+// buildMaglevTable 按权重填充查找表，表项为节点下标
+func buildMaglevTable(nodes []transport.IWeightedNode, size uint64) []int {
+	var maxWeight int64
+	for _, n := range nodes {
+		if w := nodeWeight(n); w > maxWeight {
+			maxWeight = w
+		}
+	}
+	var (
+		offsets = make([]uint64, len(nodes))
+		skips   = make([]uint64, len(nodes))
+		weights = make([]float64, len(nodes))
+		next    = make([]uint64, len(nodes))
+		filled  = make([]float64, len(nodes))
+		table   = make([]int, size)
+	)
+	for i, n := range nodes {
+		h := hash64(n.Address())
+		offsets[i] = h % size
+		skips[i] = mix64(h)%(size-1) + 1
+		weights[i] = float64(nodeWeight(n)) / float64(maxWeight)
+	}
+	for i := range table {
+		table[i] = -1
+	}
+	var count uint64
+	for iteration := 1; count < size; iteration++ {
+		for i := range nodes {
+			// 权重较低的节点按比例跳过部分轮次
+			if filled[i] >= float64(iteration)*weights[i] {
+				continue
+			}
+			c := (offsets[i] + next[i]*skips[i]) % size
+			for table[c] >= 0 {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % size
+			}
+			table[c] = i
+			next[i]++
+			filled[i]++
+			count++
+			if count == size {
+				break
+			}
+		}
+	}
+	return table
+}
@@ -0,0 +1,55 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var _ transport.Balancer = (*random)(nil)
+
+// RandomBuilder 随机负载均衡器构建器
+type RandomBuilder struct{}
+
+// Build 构建随机负载均衡器
+func (b *RandomBuilder) Build() transport.Balancer {
+	return &random{
+		r: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type random struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (r *random) intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Intn(n)
+}
+
+func (r *random) Pick(_ context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	n := nodes[r.intn(len(nodes))]
+	return n, n.Pick(), nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var _ transport.Balancer = (*ringHash)(nil)
+
+// defaultVirtualNodes 默认权重节点在哈希环上的虚拟节点数
+const defaultVirtualNodes = 160
+
+// RingHashBuilder 一致性哈希环负载均衡器构建器，使用 NewHashKeyContext 设置的键选择节点，
+// 节点在环上的虚拟节点数与初始权重成正比(以默认权重100为基准)，上下文中没有键时随机选择
+type RingHashBuilder struct {
+	VirtualNodes int // 默认权重节点的虚拟节点数，默认160
+}
+
+// Build 构建一致性哈希环负载均衡器
+func (b *RingHashBuilder) Build() transport.Balancer {
+	virtualNodes := b.VirtualNodes
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+	return &ringHash{
+		virtualNodes: virtualNodes,
+		random:       (&RandomBuilder{}).Build().(*random),
+	}
+}
+
+type ringEntry struct {
+	hash  uint64
+	index int // 节点在集合中的下标
+}
+
+// ringTable 节点集合对应的哈希环
+type ringTable struct {
+	*nodeSet
+	ring []ringEntry
+}
+
+type ringHash struct {
+	virtualNodes int
+	random       *random
+	mu           sync.RWMutex
+	ring         *ringTable
+}
+
+// Update 使用全部节点构建哈希环，过滤后的节点复用该环
+func (r *ringHash) Update(nodes []transport.IWeightedNode) {
+	var ring *ringTable
+	if len(nodes) > 0 {
+		ring = &ringTable{nodeSet: newNodeSet(nodes), ring: buildRing(nodes, r.virtualNodes)}
+	}
+	r.mu.Lock()
+	r.ring = ring
+	r.mu.Unlock()
+}
+
+func (r *ringHash) Pick(ctx context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	key, ok := HashKeyFromContext(ctx)
+	if !ok {
+		return r.random.Pick(ctx, nodes)
+	}
+	table, candidates := r.getRing(nodes)
+	ring := table.ring
+	h := hash64(key)
+	i := sort.Search(len(ring), func(i int) bool {
+		return ring[i].hash >= h
+	})
+	// 顺时针查找第一个未被过滤的节点
+	for j := 0; j < len(ring); j++ {
+		if n := candidates[ring[(i+j)%len(ring)].index]; n != nil {
+			return n, n.Pick(), nil
+		}
+	}
+	return r.random.Pick(ctx, nodes)
+}
+
+// getRing 获取包含全部候选节点的哈希环，存在环上没有的节点时按候选节点重新构建
+func (r *ringHash) getRing(nodes []transport.IWeightedNode) (*ringTable, []transport.IWeightedNode) {
+	r.mu.RLock()
+	ring := r.ring
+	r.mu.RUnlock()
+	if ring != nil {
+		if candidates, ok := ring.candidates(nodes); ok {
+			return ring, candidates
+		}
+	}
+	ring = &ringTable{nodeSet: newNodeSet(nodes), ring: buildRing(nodes, r.virtualNodes)}
+	r.mu.Lock()
+	r.ring = ring
+	r.mu.Unlock()
+	candidates, _ := ring.candidates(nodes)
+	return ring, candidates
+}
+
+// buildRing 按权重构建哈希环，节点的虚拟节点数只取决于自身权重，节点变化时其他节点的键不会迁移
+func buildRing(nodes []transport.IWeightedNode, virtualNodes int) []ringEntry {
+	ring := make([]ringEntry, 0, virtualNodes*len(nodes))
+	for index, n := range nodes {
+		replicas := int(nodeWeight(n) * int64(virtualNodes) / defaultWeight)
+		if replicas < 1 {
+			replicas = 1
+		}
+		for i := 0; i < replicas; i++ {
+			ring = append(ring, ringEntry{
+				hash:  hash64(n.Address() + "_" + strconv.Itoa(i)),
+				index: index,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	return ring
+}
+
+// hash64 计算字符串哈希，对fnv结果再做一次混合使分布更均匀
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return mix64(h.Sum64())
+}
+
+// mix64 splitmix64 的混合函数
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,44 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync/atomic"
+)
+
+var _ transport.Balancer = (*roundRobin)(nil)
+
+// RoundRobinBuilder 轮询负载均衡器构建器
+type RoundRobinBuilder struct{}
+
+// Build 构建轮询负载均衡器
+func (b *RoundRobinBuilder) Build() transport.Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+func (r *roundRobin) Pick(_ context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	n := nodes[(atomic.AddUint64(&r.next, 1)-1)%uint64(len(nodes))]
+	return n, n.Pick(), nil
+}
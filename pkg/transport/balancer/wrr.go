@@ -0,0 +1,75 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"sync"
+)
+
+var _ transport.Balancer = (*weightedRoundRobin)(nil)
+
+// WeightedRoundRobinBuilder 平滑加权轮询负载均衡器构建器，权重取自节点的初始权重
+type WeightedRoundRobinBuilder struct{}
+
+// Build 构建平滑加权轮询负载均衡器
+func (b *WeightedRoundRobinBuilder) Build() transport.Balancer {
+	return &weightedRoundRobin{
+		currentWeight: make(map[string]int64),
+	}
+}
+
+type weightedRoundRobin struct {
+	mu            sync.Mutex
+	currentWeight map[string]int64
+}
+
+func (w *weightedRoundRobin) Pick(_ context.Context, nodes []transport.IWeightedNode) (transport.IWeightedNode, transport.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, transport.ErrNoAvailable
+	}
+	w.mu.Lock()
+	var (
+		total    int64
+		selected transport.IWeightedNode
+		maxCW    int64
+	)
+	for _, n := range nodes {
+		weight := nodeWeight(n)
+		total += weight
+		cw := w.currentWeight[n.Address()] + weight
+		w.currentWeight[n.Address()] = cw
+		if selected == nil || cw > maxCW {
+			selected, maxCW = n, cw
+		}
+	}
+	w.currentWeight[selected.Address()] = maxCW - total
+	// 节点下线后清理残留的状态
+	if len(w.currentWeight) > len(nodes) {
+		alive := make(map[string]struct{}, len(nodes))
+		for _, n := range nodes {
+			alive[n.Address()] = struct{}{}
+		}
+		for addr := range w.currentWeight {
+			if _, ok := alive[addr]; !ok {
+				delete(w.currentWeight, addr)
+			}
+		}
+	}
+	w.mu.Unlock()
+	return selected, selected.Pick(), nil
+}
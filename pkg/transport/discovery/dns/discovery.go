@@ -0,0 +1,130 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var _ transport.Discover = (*Discovery)(nil)
+
+// Discovery 基于dns的服务发现，服务名以 _ 开头时解析SRV记录，如 _grpc._tcp.greeter.example.com，
+// 否则解析 host:port 的A/AAAA记录
+type Discovery struct {
+	options *Options
+}
+
+// New 创建dns服务发现
+func New(opts ...Option) *Discovery {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建dns服务发现
+func NewWithOptions(options *Options) *Discovery {
+	return &Discovery{
+		options: options,
+	}
+}
+
+// GetService 解析服务的全部实例
+func (d *Discovery) GetService(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	if d.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.options.Timeout)
+		defer cancel()
+	}
+	if strings.HasPrefix(serviceName, "_") {
+		return d.lookupSRV(ctx, serviceName)
+	}
+	return d.lookupHost(ctx, serviceName)
+}
+
+// Watch 定时重新解析，解析结果变化时返回
+func (d *Discovery) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	return newWatcher(ctx, d, serviceName), nil
+}
+
+// lookupSRV 解析SRV记录，每条记录为一个实例，权重写入元数据
+func (d *Discovery) lookupSRV(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	_, records, err := d.options.resolver.LookupSRV(ctx, "", "", serviceName)
+	if err != nil {
+		return nil, err
+	}
+	scheme := d.options.Scheme
+	if scheme == "" {
+		label, _, _ := strings.Cut(serviceName, ".")
+		scheme = strings.TrimPrefix(label, "_")
+	}
+	items := make([]*transport.ServiceInfo, 0, len(records))
+	for _, record := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		items = append(items, &transport.ServiceInfo{
+			ID:        addr,
+			Name:      serviceName,
+			Endpoints: []string{fmt.Sprintf("%s://%s", scheme, addr)},
+			Metadata: map[string]string{
+				"weight":   strconv.Itoa(int(record.Weight)),
+				"priority": strconv.Itoa(int(record.Priority)),
+			},
+		})
+	}
+	sortServices(items)
+	return items, nil
+}
+
+// lookupHost 解析A/AAAA记录，每个地址为一个实例
+func (d *Discovery) lookupHost(ctx context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	host, port, err := net.SplitHostPort(serviceName)
+	if err != nil {
+		return nil, errors.New("invalid dns discovery target, want host:port or SRV name")
+	}
+	addrs, err := d.options.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	scheme := d.options.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	items := make([]*transport.ServiceInfo, 0, len(addrs))
+	for _, ip := range addrs {
+		addr := net.JoinHostPort(ip, port)
+		items = append(items, &transport.ServiceInfo{
+			ID:        addr,
+			Name:      serviceName,
+			Endpoints: []string{fmt.Sprintf("%s://%s", scheme, addr)},
+		})
+	}
+	sortServices(items)
+	return items, nil
+}
+
+func sortServices(items []*transport.ServiceInfo) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+}
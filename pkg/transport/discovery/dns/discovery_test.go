@@ -0,0 +1,123 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeResolver struct {
+	mu    sync.Mutex
+	srv   []*net.SRV
+	hosts []string
+	err   error
+}
+
+func (f *fakeResolver) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "", f.srv, f.err
+}
+
+func (f *fakeResolver) LookupHost(_ context.Context, _ string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hosts, f.err
+}
+
+func (f *fakeResolver) set(hosts ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hosts = hosts
+}
+
+func TestSRV(t *testing.T) {
+	r := &fakeResolver{srv: []*net.SRV{
+		{Target: "b.example.com.", Port: 9000, Weight: 20},
+		{Target: "a.example.com.", Port: 9000, Weight: 10},
+	}}
+	d := New(WithResolver(r))
+	services, err := d.GetService(context.Background(), "_grpc._tcp.greeter.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("want 2 instances, got %d", len(services))
+	}
+	if services[0].Endpoints[0] != "grpc://a.example.com:9000" || services[0].Metadata["weight"] != "10" {
+		t.Fatalf("unexpected instance %+v", services[0])
+	}
+}
+
+func TestHost(t *testing.T) {
+	r := &fakeResolver{hosts: []string{"10.0.0.1", "::1"}}
+	d := New(WithResolver(r), WithScheme("grpc"))
+	services, err := d.GetService(context.Background(), "greeter.example.com:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 || services[0].Endpoints[0] != "grpc://10.0.0.1:9000" || services[1].Endpoints[0] != "grpc://[::1]:9000" {
+		t.Fatalf("unexpected services %v", services)
+	}
+	if _, err = d.GetService(context.Background(), "greeter.example.com"); err == nil {
+		t.Fatal("want invalid target error")
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	r := &fakeResolver{hosts: []string{"10.0.0.1"}}
+	d := New(WithResolver(r), WithInterval(20*time.Millisecond))
+	w, err := d.Watch(context.Background(), "greeter:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	services, err := w.Next()
+	if err != nil || len(services) != 1 {
+		t.Fatalf("want 1 instance, got %v %v", services, err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		r.set("10.0.0.1", "10.0.0.2")
+	}()
+	start := time.Now()
+	services, err = w.Next()
+	if err != nil || len(services) != 2 {
+		t.Fatalf("want 2 instances, got %v %v", services, err)
+	}
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatal("unchanged results must not be returned")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	_ = w.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must unblock next")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"net"
+	"time"
+)
+
+// Resolver dns解析器，默认使用 net.DefaultResolver
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type Option func(o *Options)
+
+// Options 配置信息
+type Options struct {
+	Interval time.Duration `json:"interval"` // 重新解析的间隔，默认值：30s
+	Timeout  time.Duration `json:"timeout"`  // 单次解析的超时时间，默认值：5s
+	Scheme   string        `json:"scheme"`   // 服务地址的协议，为空时SRV记录取服务标签，如 _grpc._tcp 为grpc，A记录为http
+	resolver Resolver
+	logger   *logger.Logger
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Interval: 30 * time.Second,
+		Timeout:  5 * time.Second,
+		resolver: net.DefaultResolver,
+		logger:   logger.With(logger.FieldMod("discovery.dns")),
+	}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.dns"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithInterval 设置重新解析的间隔
+func WithInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = interval
+	}
+}
+
+// WithTimeout 设置单次解析的超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithScheme 设置服务地址的协议
+func WithScheme(scheme string) Option {
+	return func(o *Options) {
+		o.Scheme = scheme
+	}
+}
+
+// WithResolver 设置dns解析器
+func WithResolver(resolver Resolver) Option {
+	return func(o *Options) {
+		o.resolver = resolver
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(log *logger.Logger) Option {
+	return func(o *Options) {
+		o.logger = log
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建服务发现
+func (o *Options) Build() *Discovery {
+	return NewWithOptions(o)
+}
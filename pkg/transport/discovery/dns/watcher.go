@@ -0,0 +1,82 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"reflect"
+	"time"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	discovery   *Discovery
+	serviceName string
+	last        []*transport.ServiceInfo
+	first       bool
+	timer       *time.Timer
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+func newWatcher(ctx context.Context, d *Discovery, serviceName string) *watcher {
+	w := &watcher{
+		discovery:   d,
+		serviceName: serviceName,
+		first:       true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// Next 首次调用立即解析，之后按间隔重新解析，结果变化时返回
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	for {
+		if !w.first {
+			if w.timer == nil {
+				w.timer = time.NewTimer(w.discovery.options.Interval)
+			} else {
+				w.timer.Reset(w.discovery.options.Interval)
+			}
+			select {
+			case <-w.ctx.Done():
+				return nil, w.ctx.Err()
+			case <-w.timer.C:
+			}
+		}
+		services, err := w.discovery.GetService(w.ctx, w.serviceName)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			// 首次解析失败时下次调用立即重试，否则等待下个间隔
+			return nil, err
+		}
+		if w.first || !reflect.DeepEqual(services, w.last) {
+			w.first = false
+			w.last = services
+			return services, nil
+		}
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
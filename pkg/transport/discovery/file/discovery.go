@@ -0,0 +1,101 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ transport.Discover = (*Discovery)(nil)
+
+// Discovery 基于静态文件的服务发现，文件内容为json或yaml格式的 ServiceInfo 列表，
+// 如 [{"id":"greeter-1","name":"greeter","endpoints":["grpc://127.0.0.1:9000"]}]
+type Discovery struct {
+	options *Options
+}
+
+// New 创建文件服务发现
+func New(opts ...Option) *Discovery {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewWithOptions(options)
+}
+
+// NewWithOptions 根据配置创建文件服务发现
+func NewWithOptions(options *Options) *Discovery {
+	return &Discovery{
+		options: options,
+	}
+}
+
+// GetService 读取文件中指定服务名的全部实例
+func (d *Discovery) GetService(_ context.Context, serviceName string) ([]*transport.ServiceInfo, error) {
+	services, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*transport.ServiceInfo, 0, len(services))
+	for _, service := range services {
+		if service.Name == serviceName {
+			items = append(items, service)
+		}
+	}
+	return items, nil
+}
+
+// Watch 监听文件变化
+func (d *Discovery) Watch(ctx context.Context, serviceName string) (transport.Watcher, error) {
+	return newWatcher(ctx, d, serviceName)
+}
+
+// load 读取并解析文件
+func (d *Discovery) load() ([]*transport.ServiceInfo, error) {
+	data, err := os.ReadFile(d.options.Path)
+	if err != nil {
+		return nil, err
+	}
+	format := d.options.Format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(d.options.Path)) {
+		case ".yaml", ".yml":
+			format = FormatYAML
+		default:
+			format = FormatJSON
+		}
+	}
+	var services []*transport.ServiceInfo
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &services)
+	case FormatJSON:
+		err = json.Unmarshal(data, &services)
+	default:
+		return nil, fmt.Errorf("unsupported discovery file format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse discovery file %s: %w", d.options.Path, err)
+	}
+	return services, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func next(t *testing.T, w transport.Watcher) []*transport.ServiceInfo {
+	type result struct {
+		services []*transport.ServiceInfo
+		err      error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		services, err := w.Next()
+		ch <- result{services, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		return r.services
+	case <-time.After(3 * time.Second):
+		t.Fatal("watcher next timeout")
+	}
+	return nil
+}
+
+func TestYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	data := `
+- id: greeter-1
+  name: greeter
+  version: v1.0.0
+  endpoints:
+    - grpc://127.0.0.1:9000
+  metadata:
+    zone: a
+- id: user-1
+  name: user
+  endpoints:
+    - http://127.0.0.1:8000
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	services, err := New(WithPath(path)).GetService(context.Background(), "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Version != "v1.0.0" || services[0].Endpoints[0] != "grpc://127.0.0.1:9000" || services[0].Metadata["zone"] != "a" {
+		t.Fatalf("unexpected services %+v", services)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+	write := func(data string) {
+		// 先写临时文件再替换，模拟原子更新
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`[{"id":"greeter-1","name":"greeter","endpoints":["grpc://127.0.0.1:9000"]}]`)
+	w, err := New(WithPath(path)).Watch(context.Background(), "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services := next(t, w); len(services) != 1 {
+		t.Fatalf("want 1 instance, got %v", services)
+	}
+	write(`[{"id":"greeter-1","name":"greeter","endpoints":["grpc://127.0.0.1:9000"]},{"id":"greeter-2","name":"greeter","endpoints":["grpc://127.0.0.1:9001"]}]`)
+	if services := next(t, w); len(services) != 2 || services[1].ID != "greeter-2" {
+		t.Fatalf("want 2 instances, got %v", services)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	_ = w.Stop()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must unblock next")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+)
+
+const (
+	// FormatJSON json格式
+	FormatJSON = "json"
+	// FormatYAML yaml格式
+	FormatYAML = "yaml"
+)
+
+type Option func(o *Options)
+
+// Options 配置信息
+type Options struct {
+	Path   string `json:"path"`   // 服务列表文件路径
+	Format string `json:"format"` // 文件格式：json、yaml，为空时根据文件扩展名判断
+	logger *logger.Logger
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		logger: logger.With(logger.FieldMod("discovery.file")),
+	}
+}
+
+// ScanRawConfig 扫描无封装key
+func ScanRawConfig(key string) *Options {
+	conf := DefaultOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanConfig 扫描配置
+func ScanConfig(name ...string) *Options {
+	key := "application.transport.registry.file"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanRawConfig(key)
+}
+
+// WithPath 设置服务列表文件路径
+func WithPath(path string) Option {
+	return func(o *Options) {
+		o.Path = path
+	}
+}
+
+// WithFormat 设置文件格式
+func WithFormat(format string) Option {
+	return func(o *Options) {
+		o.Format = format
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(log *logger.Logger) Option {
+	return func(o *Options) {
+		o.logger = log
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *Options) WithOptions(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建服务发现
+func (o *Options) Build() *Discovery {
+	return NewWithOptions(o)
+}
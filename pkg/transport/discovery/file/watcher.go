@@ -0,0 +1,99 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var _ transport.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	discovery   *Discovery
+	serviceName string
+	last        []*transport.ServiceInfo
+	first       bool
+	fw          *fsnotify.Watcher
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// newWatcher 监听文件所在目录，以支持编辑器替换文件与kubernetes ConfigMap的软链接切换
+func newWatcher(ctx context.Context, d *Discovery, serviceName string) (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = fw.Add(filepath.Dir(d.options.Path)); err != nil {
+		_ = fw.Close()
+		return nil, err
+	}
+	w := &watcher{
+		discovery:   d,
+		serviceName: serviceName,
+		first:       true,
+		fw:          fw,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w, nil
+}
+
+// Next 首次调用返回当前实例列表，之后在文件变化且实例列表变化时返回
+func (w *watcher) Next() ([]*transport.ServiceInfo, error) {
+	for {
+		if !w.first {
+			select {
+			case <-w.ctx.Done():
+				return nil, w.ctx.Err()
+			case err := <-w.fw.Errors:
+				return nil, err
+			case event := <-w.fw.Events:
+				if !w.match(event) {
+					continue
+				}
+			}
+		}
+		services, err := w.discovery.GetService(w.ctx, w.serviceName)
+		if err != nil {
+			return nil, err
+		}
+		if w.first || !reflect.DeepEqual(services, w.last) {
+			w.first = false
+			w.last = services
+			return services, nil
+		}
+	}
+}
+
+// match 事件是否与服务列表文件相关，ConfigMap 更新时切换的是 ..data 软链接
+func (w *watcher) match(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	return filepath.Clean(event.Name) == filepath.Clean(w.discovery.options.Path) || strings.HasPrefix(name, "..")
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return w.fw.Close()
+}
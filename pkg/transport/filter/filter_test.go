@@ -0,0 +1,131 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"context"
+	"github.com/go-ceres/ceres"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"testing"
+)
+
+func newNode(address, version string, md map[string]string) transport.Node {
+	return transport.NewNode("grpc", address, &transport.ServiceInfo{ID: address, Version: version, Metadata: md})
+}
+
+func addresses(nodes []transport.Node) string {
+	var s string
+	for _, n := range nodes {
+		s += n.Address() + " "
+	}
+	return s
+}
+
+func TestZone(t *testing.T) {
+	builder := &transport.DefaultWeightNodeBuilder{}
+	nodes := []transport.Node{
+		builder.Build(newNode("a1", "", map[string]string{"region": "sh", "zone": "sh-a"})),
+		builder.Build(newNode("a2", "", map[string]string{"region": "sh", "zone": "sh-a"})),
+		builder.Build(newNode("b1", "", map[string]string{"region": "sh", "zone": "sh-b"})),
+		builder.Build(newNode("c1", "", map[string]string{"region": "bj", "zone": "bj-a"})),
+	}
+	f := Zone(WithZone("sh-a"), WithRegion("sh"))
+	if got := addresses(f(context.Background(), nodes)); got != "a1 a2 " {
+		t.Fatalf("want local zone nodes, got %s", got)
+	}
+	// 本分区一个节点持续失败，健康节点占比低于阈值后溢出到同地域
+	wn := nodes[0].(transport.IWeightedNode)
+	for i := 0; i < 100; i++ {
+		wn.Pick()(context.Background(), transport.DoneInfo{Err: context.DeadlineExceeded})
+	}
+	if got := addresses(f(context.Background(), nodes)); got != "a1 a2 b1 " {
+		t.Fatalf("want spillover to region, got %s", got)
+	}
+	// 本地分区没有节点时使用同地域节点
+	if got := addresses(Zone(WithZone("sh-c"), WithRegion("sh"))(context.Background(), nodes)); got != "b1 a1 a2 " && got != "a1 a2 b1 " {
+		t.Fatalf("want region nodes, got %s", got)
+	}
+	if got := len(Zone(WithZone("gz-a"), WithRegion("gz"))(context.Background(), nodes)); got != 4 {
+		t.Fatalf("want all nodes, got %d", got)
+	}
+	// 未指定分区时使用应用所在分区
+	f = Zone()
+	if got := len(f(context.Background(), nodes)); got != 4 {
+		t.Fatalf("want all nodes without zone, got %d", got)
+	}
+	ceres.SetAppRegion("bj")
+	ceres.SetAppZone("bj-a")
+	defer ceres.SetAppRegion("")
+	defer ceres.SetAppZone("")
+	if got := addresses(f(context.Background(), nodes)); got != "c1 " {
+		t.Fatalf("want app zone nodes, got %s", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	var nodes []transport.Node
+	for _, v := range []string{"v1.0.0", "v1.2.3", "v1.9.0", "v2.0.0-rc.1", "v2.0.0", "v2.1.5", "v3.0.0", "bad"} {
+		nodes = append(nodes, newNode(v, v, nil))
+	}
+	cases := map[string]string{
+		">=1.2.0 <2.0.0":   "v1.2.3 v1.9.0 ",
+		"^1.2":             "v1.2.3 v1.9.0 ",
+		"~2.1.0":           "v2.1.5 ",
+		"2.x":              "v2.0.0 v2.1.5 ",
+		"1.0.0 || >=3":     "v1.0.0 v3.0.0 ",
+		">=2.0.0-rc.0, <2": "v2.0.0-rc.1 ",
+		"!=1.0.0, <2.0.0":  "v1.2.3 v1.9.0 ",
+		">2.0.0-rc.1 <2.1": "v2.0.0 ",
+		"*":                "v1.0.0 v1.2.3 v1.9.0 v2.0.0 v2.1.5 v3.0.0 ",
+	}
+	for expr, want := range cases {
+		if got := addresses(MustVersion(expr)(context.Background(), nodes)); got != want {
+			t.Errorf("%s: want %q, got %q", expr, want, got)
+		}
+	}
+	for _, expr := range []string{"", ">=abc", "1.2.3.4"} {
+		if _, err := Version(expr); err == nil {
+			t.Errorf("%s: want error", expr)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	nodes := []transport.Node{
+		newNode("n1", "", map[string]string{"env": "prod", "tier": "web"}),
+		newNode("n2", "", map[string]string{"env": "prod", "tier": "cache", "gpu": "true"}),
+		newNode("n3", "", map[string]string{"env": "test", "tier": "web", "canary": "true"}),
+		newNode("n4", "", nil),
+	}
+	cases := map[string]string{
+		"env=prod":                     "n1 n2 ",
+		"env==prod, tier!=cache":       "n1 ",
+		"tier in (web, cache),!canary": "n1 n2 ",
+		"env notin (prod)":             "n3 n4 ",
+		"gpu":                          "n2 ",
+		"!gpu,env in (prod,test)":      "n1 n3 ",
+	}
+	for expr, want := range cases {
+		if got := addresses(MustMetadata(expr)(context.Background(), nodes)); got != want {
+			t.Errorf("%s: want %q, got %q", expr, want, got)
+		}
+	}
+	for _, expr := range []string{"", "env in prod", "=prod", "env foo (a)"} {
+		if _, err := Metadata(expr); err == nil {
+			t.Errorf("%s: want error", expr)
+		}
+	}
+}
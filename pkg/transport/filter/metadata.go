@@ -0,0 +1,175 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"strings"
+)
+
+// requirement 单个元数据条件
+type requirement struct {
+	key    string
+	op     string // =、!=、in、notin、exists、!exists
+	values []string
+}
+
+func (r requirement) match(md map[string]string) bool {
+	v, ok := md[r.key]
+	switch r.op {
+	case "exists":
+		return ok
+	case "!exists":
+		return !ok
+	case "=", "in":
+		return ok && contains(r.values, v)
+	case "!=", "notin":
+		return !ok || !contains(r.values, v)
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata 元数据过滤器，只保留元数据满足选择器表达式的节点，
+// 多个条件用逗号分隔且需同时满足，支持 key=value、key==value、key!=value、
+// key in (a,b)、key notin (a,b)、key(存在)、!key(不存在)，如 "env=prod,canary notin (true),gpu"
+func Metadata(expr string) (transport.NodeFilter, error) {
+	reqs, err := parseSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, nodes []transport.Node) []transport.Node {
+		res := make([]transport.Node, 0, len(nodes))
+		for _, n := range nodes {
+			var md map[string]string
+			if info := n.ServiceInfo(); info != nil {
+				md = info.Metadata
+			}
+			matched := true
+			for _, req := range reqs {
+				if !req.match(md) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				res = append(res, n)
+			}
+		}
+		return res
+	}, nil
+}
+
+// MustMetadata 同 Metadata，表达式错误时panic
+func MustMetadata(expr string) transport.NodeFilter {
+	f, err := Metadata(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// parseSelector 解析选择器表达式
+func parseSelector(expr string) ([]requirement, error) {
+	var reqs []requirement
+	for _, part := range splitSelector(expr) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		req, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("filter: empty metadata selector %q", expr)
+	}
+	return reqs, nil
+}
+
+// splitSelector 按逗号切分表达式，忽略括号内的逗号
+func splitSelector(expr string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+// parseRequirement 解析单个条件
+func parseRequirement(s string) (requirement, error) {
+	if strings.HasPrefix(s, "!") && !strings.ContainsAny(s, "=()") {
+		return requirement{key: strings.TrimSpace(s[1:]), op: "!exists"}, checkKey(s[1:], s)
+	}
+	for _, op := range []string{"!=", "==", "="} {
+		if i := strings.Index(s, op); i >= 0 {
+			key, value := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(op):])
+			if op == "==" {
+				op = "="
+			}
+			return requirement{key: key, op: op, values: []string{value}}, checkKey(key, s)
+		}
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 1 {
+		return requirement{key: fields[0], op: "exists"}, checkKey(fields[0], s)
+	}
+	if len(fields) < 3 || (fields[1] != "in" && fields[1] != "notin") {
+		return requirement{}, fmt.Errorf("filter: invalid metadata requirement %q", s)
+	}
+	list := strings.TrimSpace(strings.Join(fields[2:], " "))
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return requirement{}, fmt.Errorf("filter: invalid value set in %q", s)
+	}
+	var values []string
+	for _, v := range strings.Split(list[1:len(list)-1], ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return requirement{key: fields[0], op: fields[1], values: values}, checkKey(fields[0], s)
+}
+
+func checkKey(key, s string) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("filter: missing key in %q", s)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver 语义化版本
+type semver struct {
+	major, minor, patch int64
+	pre                 string
+}
+
+// parseVersion 解析版本号，支持 v 前缀，缺失的部分视为0
+func parseVersion(s string) (semver, error) {
+	v, parts, err := parsePartial(s)
+	if err != nil {
+		return semver{}, err
+	}
+	if parts == 0 {
+		return semver{}, fmt.Errorf("filter: invalid version %q", s)
+	}
+	return v, nil
+}
+
+// parsePartial 解析可能不完整的版本号，返回明确给出的部分数，遇到 x、X、* 通配符时停止
+func parsePartial(s string) (v semver, parts int, err error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.pre = s[i+1:]
+		s = s[:i]
+	}
+	if s == "" {
+		return v, 0, fmt.Errorf("filter: invalid version %q", s)
+	}
+	nums := [3]*int64{&v.major, &v.minor, &v.patch}
+	for i, field := range strings.Split(s, ".") {
+		if i >= len(nums) {
+			return v, 0, fmt.Errorf("filter: invalid version %q", s)
+		}
+		if field == "x" || field == "X" || field == "*" {
+			return v, i, nil
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("filter: invalid version %q", s)
+		}
+		*nums[i] = n
+		parts = i + 1
+	}
+	return v, parts, nil
+}
+
+// compare 比较版本大小
+func (v semver) compare(o semver) int {
+	for _, d := range [3]int64{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			if d > 0 {
+				return 1
+			}
+			return -1
+		}
+	}
+	return comparePre(v.pre, o.pre)
+}
+
+// comparePre 比较预发布版本，正式版本大于预发布版本
+func comparePre(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.ParseInt(as[i], 10, 64)
+		bn, bErr := strconv.ParseInt(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] < bs[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// comparator 单个版本比较条件
+type comparator struct {
+	op       string
+	v        semver
+	implicit bool // 由 ^、~ 或通配符展开生成的边界
+}
+
+func (c comparator) match(v semver) bool {
+	r := v.compare(c.v)
+	switch c.op {
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case "!=":
+		return r != 0
+	default:
+		return r == 0
+	}
+}
+
+// versionRange 版本范围，外层为或关系，内层为且关系
+type versionRange [][]comparator
+
+// 与 npm 语义一致，预发布版本仅在同一条件组中显式包含相同主次修订号的预发布版本时才匹配
+func (r versionRange) match(v semver) bool {
+	for _, and := range r {
+		if v.pre != "" && !allowPre(and, v) {
+			continue
+		}
+		matched := true
+		for _, c := range and {
+			if !c.match(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func allowPre(and []comparator, v semver) bool {
+	for _, c := range and {
+		if !c.implicit && c.v.pre != "" && c.v.major == v.major && c.v.minor == v.minor && c.v.patch == v.patch {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange 解析版本范围表达式，支持 >、>=、<、<=、=、!=、^、~、通配符 1.x，
+// 空格或逗号分隔表示且，|| 分隔表示或，如 ">=1.2.0 <2.0.0 || ^3.1"
+func parseRange(expr string) (versionRange, error) {
+	var r versionRange
+	for _, alt := range strings.Split(expr, "||") {
+		fields := strings.FieldsFunc(alt, func(c rune) bool {
+			return c == ' ' || c == ','
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("filter: empty version range in %q", expr)
+		}
+		var and []comparator
+		for _, field := range fields {
+			cs, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, cs...)
+		}
+		r = append(r, and)
+	}
+	return r, nil
+}
+
+// parseComparator 解析单个比较条件，^、~ 与通配符展开为上下界
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+	if s == "*" || s == "x" || s == "X" {
+		return nil, nil
+	}
+	v, parts, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "^":
+		upper := semver{major: v.major + 1}
+		switch {
+		case v.major == 0 && v.minor == 0 && parts == 3:
+			upper = semver{patch: v.patch + 1}
+		case v.major == 0 && parts >= 2:
+			upper = semver{minor: v.minor + 1}
+		}
+		return bounds(v, upper), nil
+	case "~":
+		if parts <= 1 {
+			return bounds(v, semver{major: v.major + 1}), nil
+		}
+		return bounds(v, semver{major: v.major, minor: v.minor + 1}), nil
+	}
+	if parts < 3 {
+		// 不完整的版本号视为通配符
+		if parts == 0 {
+			return nil, nil
+		}
+		upper := semver{major: v.major + 1}
+		if parts == 2 {
+			upper = semver{major: v.major, minor: v.minor + 1}
+		}
+		switch op {
+		case "", "=":
+			return bounds(v, upper), nil
+		case ">":
+			return []comparator{{op: ">=", v: upper, implicit: true}}, nil
+		case "<=":
+			return []comparator{{op: "<", v: upper, implicit: true}}, nil
+		}
+	}
+	return []comparator{{op: op, v: v}}, nil
+}
+
+// bounds 生成 [lower, upper) 区间，上界排除预发布版本
+func bounds(lower, upper semver) []comparator {
+	upper.pre = "0"
+	return []comparator{{op: ">=", v: lower, implicit: lower.pre == ""}, {op: "<", v: upper, implicit: true}}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+// Version 版本过滤器，只保留服务版本满足范围表达式的节点，
+// 表达式如 ">=1.2.0 <2.0.0"、"^1.4"、"~2.1.0"、"1.x || >=3.0.0"
+func Version(expr string) (transport.NodeFilter, error) {
+	r, err := parseRange(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, nodes []transport.Node) []transport.Node {
+		res := make([]transport.Node, 0, len(nodes))
+		for _, n := range nodes {
+			info := n.ServiceInfo()
+			if info == nil {
+				continue
+			}
+			v, err := parseVersion(info.Version)
+			if err != nil {
+				continue
+			}
+			if r.match(v) {
+				res = append(res, n)
+			}
+		}
+		return res
+	}, nil
+}
+
+// MustVersion 同 Version，表达式错误时panic
+func MustVersion(expr string) transport.NodeFilter {
+	f, err := Version(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
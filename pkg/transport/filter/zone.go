@@ -0,0 +1,148 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"context"
+	"github.com/go-ceres/ceres"
+	"github.com/go-ceres/ceres/pkg/transport"
+)
+
+const (
+	// MetadataRegion 服务信息中地域的键
+	MetadataRegion = "region"
+	// MetadataZone 服务信息中分区的键
+	MetadataZone = "zone"
+)
+
+type ZoneOption func(o *ZoneOptions)
+
+// ZoneOptions 同分区优先过滤器配置
+type ZoneOptions struct {
+	Zone           string  `json:"zone"`           // 本地分区，默认为应用所在分区
+	Region         string  `json:"region"`         // 本地地域，默认为应用所在地域，溢出时优先同地域节点
+	Threshold      float64 `json:"threshold"`      // 本分区健康节点占比低于该值时溢出到其他分区，默认0.7
+	MinNodes       int     `json:"minNodes"`       // 本分区健康节点数低于该值时溢出到其他分区，默认1
+	HealthySuccess float64 `json:"healthySuccess"` // 节点成功率不低于该值视为健康，默认0.8
+}
+
+// DefaultZoneOptions 默认配置
+func DefaultZoneOptions() *ZoneOptions {
+	return &ZoneOptions{
+		Zone:           ceres.AppZone(),
+		Region:         ceres.AppRegion(),
+		Threshold:      0.7,
+		MinNodes:       1,
+		HealthySuccess: 0.8,
+	}
+}
+
+// WithZone 设置本地分区
+func WithZone(zone string) ZoneOption {
+	return func(o *ZoneOptions) {
+		o.Zone = zone
+	}
+}
+
+// WithRegion 设置本地地域
+func WithRegion(region string) ZoneOption {
+	return func(o *ZoneOptions) {
+		o.Region = region
+	}
+}
+
+// WithThreshold 设置溢出阈值
+func WithThreshold(threshold float64) ZoneOption {
+	return func(o *ZoneOptions) {
+		o.Threshold = threshold
+	}
+}
+
+// WithMinNodes 设置本分区最少健康节点数
+func WithMinNodes(minNodes int) ZoneOption {
+	return func(o *ZoneOptions) {
+		o.MinNodes = minNodes
+	}
+}
+
+// WithHealthySuccess 设置健康节点的最低成功率
+func WithHealthySuccess(success float64) ZoneOption {
+	return func(o *ZoneOptions) {
+		o.HealthySuccess = success
+	}
+}
+
+// Zone 同分区优先过滤器，优先选择同分区的节点，
+// 本分区健康节点不足时溢出到同地域的其他分区，同地域也没有节点时使用全部节点
+func Zone(opts ...ZoneOption) transport.NodeFilter {
+	o := DefaultZoneOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(_ context.Context, nodes []transport.Node) []transport.Node {
+		// 未指定时使用应用所在的分区与地域，应用可能在创建过滤器之后才设置
+		zone, region := o.Zone, o.Region
+		if zone == "" {
+			zone = ceres.AppZone()
+		}
+		if region == "" {
+			region = ceres.AppRegion()
+		}
+		if zone == "" {
+			return nodes
+		}
+		var (
+			local    = make([]transport.Node, 0, len(nodes))
+			regional []transport.Node
+			healthy  int
+		)
+		for _, n := range nodes {
+			switch {
+			case metadata(n, MetadataZone) == zone:
+				local = append(local, n)
+				if isHealthy(n, o.HealthySuccess) {
+					healthy++
+				}
+			case region != "" && metadata(n, MetadataRegion) == region:
+				regional = append(regional, n)
+			}
+		}
+		if len(local) > 0 && healthy >= o.MinNodes && float64(healthy) >= o.Threshold*float64(len(local)) {
+			return local
+		}
+		// 本分区容量不足，溢出到同地域的其他分区
+		if len(regional) > 0 {
+			return append(local, regional...)
+		}
+		return nodes
+	}
+}
+
+// metadata 获取节点服务信息中的元数据
+func metadata(n transport.Node, key string) string {
+	if info := n.ServiceInfo(); info != nil {
+		return info.Metadata[key]
+	}
+	return ""
+}
+
+// isHealthy 节点是否健康，没有统计信息的节点视为健康
+func isHealthy(n transport.Node, success float64) bool {
+	if stats, ok := n.(transport.NodeStats); ok {
+		return stats.Success() >= success
+	}
+	return true
+}
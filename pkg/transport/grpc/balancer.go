@@ -16,40 +16,140 @@
 package grpc
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/go-ceres/ceres/pkg/transport"
+	_ "github.com/go-ceres/ceres/pkg/transport/balancer"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/serviceconfig"
+	"io"
+	"sync"
+	"sync/atomic"
 )
 
 const (
 	balanceName = "selector"
 )
 
+var (
+	// outliers 客户端异常节点检测配置，服务配置只传递键，保留 ErrHandler、OnEvent 与 Logger
+	outliers   sync.Map
+	outlierSeq uint64
+)
+
 func init() {
-	b := base.NewBalancerBuilder(
-		balanceName,
-		&pickerBuilder{
-			selectorBuilder: transport.GetSelectorBuilder(),
-		},
-		base.Config{
-			HealthCheck: true,
-		},
-	)
-	balancer.Register(b)
+	balancer.Register(&balancerBuilder{})
+}
+
+// storeOutlier 保存异常节点检测配置，返回服务配置中使用的键
+func storeOutlier(opts *transport.OutlierOptions) string {
+	key := fmt.Sprintf("outlier-%d", atomic.AddUint64(&outlierSeq, 1))
+	outliers.Store(key, opts)
+	return key
+}
+
+// lbConfig 负载均衡配置，Balancer 为 transport 中注册的负载均衡器名称，Outlier 为异常节点检测配置，
+// OutlierKey 存在时优先使用客户端保存的配置
+type lbConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+	Balancer                          string                    `json:"balancer,omitempty"`
+	Outlier                           *transport.OutlierOptions `json:"outlier,omitempty"`
+	OutlierKey                        string                    `json:"outlierKey,omitempty"`
+}
+
+// balancerBuilder 基于 transport.Selector 的grpc负载均衡器构建器
+type balancerBuilder struct{}
+
+func (b *balancerBuilder) Name() string {
+	return balanceName
+}
+
+func (b *balancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &pickerBuilder{}
+	return &selectorBalancer{
+		Balancer:      base.NewBalancerBuilder(balanceName, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pickerBuilder: pb,
+	}
+}
+
+func (b *balancerBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	cfg := &lbConfig{}
+	if len(js) > 0 {
+		if err := json.Unmarshal(js, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Outlier != nil {
+		// 未配置的字段使用默认值
+		cfg.Outlier = transport.DefaultOutlierOptions()
+		if err := json.Unmarshal(js, &struct {
+			Outlier *transport.OutlierOptions `json:"outlier"`
+		}{cfg.Outlier}); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.OutlierKey != "" {
+		if opts, ok := outliers.Load(cfg.OutlierKey); ok {
+			cfg.Outlier = opts.(*transport.OutlierOptions)
+		}
+	}
+	if cfg.Balancer != "" {
+		if _, ok := transport.GetBalancerBuilder(cfg.Balancer); !ok {
+			return nil, fmt.Errorf("grpc: unknown balancer %q", cfg.Balancer)
+		}
+	}
+	return cfg, nil
+}
+
+// selectorBalancer 在连接状态更新时读取负载均衡配置
+type selectorBalancer struct {
+	balancer.Balancer
+	pickerBuilder *pickerBuilder
+	outlierKey    string
+}
+
+// Close 关闭负载均衡器，选择器实现 io.Closer 时一并关闭
+func (b *selectorBalancer) Close() {
+	if b.outlierKey != "" {
+		outliers.Delete(b.outlierKey)
+	}
+	if closer, ok := b.pickerBuilder.selector.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	b.Balancer.Close()
+}
+
+func (b *selectorBalancer) UpdateClientConnState(state balancer.ClientConnState) error {
+	if cfg, ok := state.BalancerConfig.(*lbConfig); ok {
+		b.pickerBuilder.balancer = cfg.Balancer
+		b.pickerBuilder.outlier = cfg.Outlier
+		b.outlierKey = cfg.OutlierKey
+	}
+	return b.Balancer.UpdateClientConnState(state)
 }
 
 type pickerBuilder struct {
-	selectorBuilder transport.SelectorBuilder
+	balancer string
+	outlier  *transport.OutlierOptions
+	selector transport.Selector
 }
 
-// Build 构建匹配器
+// Build 构建匹配器，同一连接复用同一个选择器以保留节点统计信息
 func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 	// 获取selector
 	if len(info.ReadySCs) == 0 {
 		// Block the RPC until a new picker is available via UpdateState().
 		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
 	}
+	if pb.selector == nil {
+		selector, err := transport.BuildSelectorWithOutlier(pb.balancer, pb.outlier)
+		if err != nil {
+			return base.NewErrPicker(err)
+		}
+		pb.selector = selector
+	}
 	nodes := make([]transport.Node, 0, len(info.ReadySCs))
 	for conn, info := range info.ReadySCs {
 		ins, _ := info.Address.Attributes.Value("rawServiceInstance").(*transport.ServiceInfo)
@@ -58,11 +158,10 @@ func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 			subConn: conn,
 		})
 	}
-	p := &picker{
-		selector: pb.selectorBuilder.Build(),
+	pb.selector.Store(nodes)
+	return &picker{
+		selector: pb.selector,
 	}
-	p.selector.Store(nodes)
-	return p
 }
 
 type picker struct {
@@ -0,0 +1,67 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientOutlierOnEvent(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	var handled, events int32
+	opts := transport.DefaultOutlierOptions()
+	opts.ConsecutiveFailures = 2
+	opts.ErrHandler = func(err error) bool {
+		atomic.AddInt32(&handled, 1)
+		return err != nil
+	}
+	opts.OnEvent = func(event transport.OutlierEvent) {
+		if event.Type == transport.OutlierConsecutiveFailures {
+			atomic.AddInt32(&events, 1)
+		}
+	}
+	cc, err := NewClient(WithClientEndpoint(lis.Addr().String()), WithClientOutlier(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	// 服务端未注册服务，调用返回 Unimplemented
+	for i := 0; i < 2; i++ {
+		if err := cc.Invoke(context.Background(), "/test.Service/Method", &emptypb.Empty{}, &emptypb.Empty{}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if atomic.LoadInt32(&handled) == 0 {
+		t.Fatal("ErrHandler not called")
+	}
+	if atomic.LoadInt32(&events) != 1 {
+		t.Fatalf("expected 1 ejection event, got %d", events)
+	}
+}
@@ -17,15 +17,18 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/go-ceres/ceres/pkg/common/logger"
 	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/go-ceres/ceres/pkg/transport/retry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	grpcmd "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
 	"time"
 )
 
@@ -51,8 +54,12 @@ func NewClientWithOptions(options *ClientOptions) (*grpc.ClientConn, error) {
 
 		dialOptions = append(dialOptions, grpc.WithBlock())
 	}
+	var retrier *retry.Retrier
+	if options.Retry != nil && options.Retry.MaxAttempts > 1 {
+		retrier = options.Retry.Build()
+	}
 	ints := []grpc.UnaryClientInterceptor{
-		unaryClientInterceptor(options.middleware, options.Timeout, options.filters),
+		unaryClientInterceptor(options.middleware, options.Timeout, options.filters, retrier),
 	}
 	if len(options.interceptors) > 0 {
 		ints = append(ints, options.interceptors...)
@@ -61,8 +68,9 @@ func NewClientWithOptions(options *ClientOptions) (*grpc.ClientConn, error) {
 		ints = append(ints, debugUnaryClientInterceptor(options.Endpoint))
 	}
 
+	svcConfig, outlierKey := serviceConfig(options.Balancer, options.Outlier)
 	dialOptions = append(dialOptions,
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, options.Balancer)),
+		grpc.WithDefaultServiceConfig(svcConfig),
 		grpc.WithChainUnaryInterceptor(ints...),
 	)
 	if options.discovery != nil {
@@ -72,6 +80,7 @@ func NewClientWithOptions(options *ClientOptions) (*grpc.ClientConn, error) {
 			logger:           options.logger,
 			insecure:         options.Insecure,
 			debugLogDisabled: options.Debug,
+			subset:           options.Subset,
 		}
 		dialOptions = append(dialOptions, grpc.WithResolvers(b))
 	}
@@ -83,6 +92,9 @@ func NewClientWithOptions(options *ClientOptions) (*grpc.ClientConn, error) {
 	}
 	cc, err := grpc.DialContext(ctx, options.Endpoint, dialOptions...)
 	if err != nil {
+		if outlierKey != "" {
+			outliers.Delete(outlierKey)
+		}
 		options.logger.Error("dial grpc server err", logger.FieldError(err))
 		return nil, err
 	}
@@ -90,6 +102,23 @@ func NewClientWithOptions(options *ClientOptions) (*grpc.ClientConn, error) {
 	return cc, nil
 }
 
+// serviceConfig 负载均衡服务配置，transport 中注册的负载均衡器通过选择器实现，其他名称按grpc负载均衡策略处理，
+// 开启异常节点检测时同时返回保存配置的键
+func serviceConfig(name string, outlier *transport.OutlierOptions) (string, string) {
+	cfg := &lbConfig{Outlier: outlier}
+	if name != "" && name != balanceName {
+		if _, ok := transport.GetBalancerBuilder(name); !ok {
+			return fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, name), ""
+		}
+		cfg.Balancer = name
+	}
+	if outlier != nil {
+		cfg.OutlierKey = storeOutlier(outlier)
+	}
+	js, _ := json.Marshal(cfg)
+	return fmt.Sprintf(`{"loadBalancingConfig": [{"%s":%s}]}`, balanceName, js), cfg.OutlierKey
+}
+
 // debugUnaryClientInterceptor 日志拦截器
 func debugUnaryClientInterceptor(addr string) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
@@ -112,14 +141,14 @@ func debugUnaryClientInterceptor(addr string) grpc.UnaryClientInterceptor {
 }
 
 // unaryClientInterceptor 过滤器设置
-func unaryClientInterceptor(ms []transport.Middleware, timeout time.Duration, filters []transport.NodeFilter) grpc.UnaryClientInterceptor {
+func unaryClientInterceptor(ms []transport.Middleware, timeout time.Duration, filters []transport.NodeFilter, retrier *retry.Retrier) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		metadata := AcquireMetadata()
 		defer ReleaseMetadata(metadata)
 		metadata.endpoint = cc.Target()
 		metadata.operation = method
 		metadata.requestHeader = headerCarrier{}
-		metadata.requestHeader = headerCarrier{}
+		metadata.replyHeader = headerCarrier{}
 		metadata.nodeFilters = filters
 		ctx = transport.NewMetadataClientContext(ctx, metadata)
 		if timeout > 0 {
@@ -137,7 +166,10 @@ func unaryClientInterceptor(ms []transport.Middleware, timeout time.Duration, fi
 				}
 				ctx = grpcmd.AppendToOutgoingContext(ctx, KeyValues...)
 			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			if retrier == nil {
+				return reply, invoker(ctx, method, req, reply, cc, opts...)
+			}
+			return retryInvoke(ctx, retrier, method, req, reply, cc, invoker, opts...)
 		}
 		if len(ms) > 0 {
 			h = transport.MiddlewareChain(ms...)(h)
@@ -148,3 +180,21 @@ func unaryClientInterceptor(ms []transport.Middleware, timeout time.Duration, fi
 		return err
 	}
 }
+
+// retryInvoke 按重试策略调用，每次尝试都会经过负载均衡重新选择节点
+func retryInvoke(ctx context.Context, retrier *retry.Retrier, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (interface{}, error) {
+	msg, isProto := reply.(proto.Message)
+	out, err := retrier.Do(ctx, func(ctx context.Context, attempt int) (interface{}, error) {
+		// 对冲请求会并发执行，每次尝试使用独立的响应对象
+		if retrier.Hedging() && isProto {
+			attemptReply := msg.ProtoReflect().New().Interface()
+			return attemptReply, invoker(ctx, method, req, attemptReply, cc, opts...)
+		}
+		return reply, invoker(ctx, method, req, reply, cc, opts...)
+	})
+	if res, ok := out.(proto.Message); ok && isProto && res != msg {
+		proto.Reset(msg)
+		proto.Merge(msg, res)
+	}
+	return reply, err
+}
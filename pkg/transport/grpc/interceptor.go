@@ -43,6 +43,8 @@ func (w *wrappedStream) Context() context.Context {
 // unaryServerInterceptor is a gRPC unary server interceptor
 func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		s.inflight.Add(1)
+		defer s.inflight.Add(-1)
 		ctx, cancel := ic.Merge(ctx, s.baseContext)
 		defer cancel()
 		md, _ := grpcMetadata.FromIncomingContext(ctx)
@@ -82,6 +84,8 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 // streamServerInterceptor is a gRPC stream server interceptor
 func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		s.inflight.Add(1)
+		defer s.inflight.Add(-1)
 		ctx, cancel := ic.Merge(ss.Context(), s.baseContext)
 		defer cancel()
 		tr := AcquireMetadata()
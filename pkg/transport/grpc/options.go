@@ -21,6 +21,7 @@ import (
 	"github.com/go-ceres/ceres/pkg/common/config"
 	"github.com/go-ceres/ceres/pkg/common/logger"
 	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/go-ceres/ceres/pkg/transport/retry"
 	"google.golang.org/grpc"
 	"time"
 )
@@ -153,10 +154,21 @@ func WithServerMiddleware(mws ...transport.Middleware) ServerOption {
 	}
 }
 
-// AddServerMiddleware 添加中间件
-func AddServerMiddleware(selector string, mw transport.Middleware) ServerOption {
+// AddServerMiddleware 按选择器添加中间件，支持精确匹配、通配符(/helloworld.Greeter/*)、
+// 正则(regex:^/helloworld\.)与排除(!/grpc.health.v1.Health/Check)
+func AddServerMiddleware(selector string, mw ...transport.Middleware) ServerOption {
 	return func(o *ServerOptions) {
-		o.middleware.Add(selector, mw)
+		o.middleware.Add(selector, mw...)
+	}
+}
+
+// MiddlewareRule 中间件匹配规则
+type MiddlewareRule = matcher.Rule
+
+// AddServerMiddlewareRule 添加中间件匹配规则，可以设置多个选择器、排除项与优先级
+func AddServerMiddlewareRule(rule MiddlewareRule) ServerOption {
+	return func(o *ServerOptions) {
+		o.middleware.AddRule(rule)
 	}
 }
 
@@ -224,7 +236,10 @@ type ClientOptions struct {
 	Timeout      time.Duration                 `json:"timeout"`     // 超时
 	DialTimeout  time.Duration                 `json:"dialTimeout"` // 调用超时
 	OnDialError  string                        `json:"OnDialError"` // 构建错误处理 panic | error
-	Balancer     string                        `json:"balancer"`    // 负载均衡器名称
+	Balancer     string                        `json:"balancer"`    // 负载均衡器名称，如 p2c、round_robin、weighted_round_robin、random、least_request、ring_hash、maglev
+	Retry        *retry.Options                `json:"retry"`       // 重试策略，默认不重试
+	Subset       *transport.SubsetOptions      `json:"subset"`      // 实例子集划分，默认不开启
+	Outlier      *transport.OutlierOptions     `json:"outlier"`     // 异常节点检测，默认不开启，仅对 transport 中注册的负载均衡器生效
 	discovery    transport.Discover            // 服务发现
 	middleware   []transport.Middleware        // 中间件
 	interceptors []grpc.UnaryClientInterceptor // 拦截器
@@ -240,6 +255,8 @@ func DefaultClientOptions() *ClientOptions {
 		Timeout:     3 * time.Second,
 		DialTimeout: 3 * time.Second,
 		Balancer:    balanceName,
+		Retry:       retry.DefaultOptions(),
+		Subset:      transport.DefaultSubsetOptions(),
 		Insecure:    true,
 		Debug:       false,
 		logger:      logger.With(logger.FieldMod("transport.grpc.client")),
@@ -318,6 +335,43 @@ func WithClientBalancer(Balancer string) ClientOption {
 	}
 }
 
+// WithClientOutlier 开启异常节点检测，opts 可通过 transport.DefaultOutlierOptions 创建
+func WithClientOutlier(opts *transport.OutlierOptions) ClientOption {
+	return func(o *ClientOptions) {
+		o.Outlier = opts
+	}
+}
+
+// WithClientRetry 设置重试策略
+func WithClientRetry(opts ...retry.Option) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Retry == nil {
+			o.Retry = retry.DefaultOptions()
+		}
+		o.Retry = o.Retry.WithOption(opts...)
+	}
+}
+
+// WithClientSubsetSize 设置实例子集大小，小于等于0时不开启
+func WithClientSubsetSize(size int) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Subset == nil {
+			o.Subset = transport.DefaultSubsetOptions()
+		}
+		o.Subset.Size = size
+	}
+}
+
+// WithClientSubsetKey 设置子集划分的客户端标识，默认使用应用ID
+func WithClientSubsetKey(key string) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Subset == nil {
+			o.Subset = transport.DefaultSubsetOptions()
+		}
+		o.Subset.Key = key
+	}
+}
+
 func WithClientDiscovery(discovery transport.Discover) ClientOption {
 	return func(o *ClientOptions) {
 		o.discovery = discovery
@@ -79,6 +79,7 @@ type discoveryResolverBuilder struct {
 	logger           *logger.Logger
 	insecure         bool
 	debugLogDisabled bool
+	subset           *transport.SubsetOptions
 }
 
 func (d *discoveryResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
@@ -115,6 +116,7 @@ func (d *discoveryResolverBuilder) Build(target resolver.Target, cc resolver.Cli
 		insecure:         d.insecure,
 		logger:           d.logger,
 		debugLogDisabled: d.debugLogDisabled,
+		subset:           d.subset,
 	}
 	go r.watch()
 	return r, nil
@@ -133,6 +135,7 @@ type discoveryResolver struct {
 	logger           *logger.Logger
 	insecure         bool
 	debugLogDisabled bool
+	subset           *transport.SubsetOptions
 }
 
 func (r *discoveryResolver) watch() {
@@ -156,8 +159,9 @@ func (r *discoveryResolver) watch() {
 }
 
 func (r *discoveryResolver) update(ins []*transport.ServiceInfo) {
-	addrs := make([]resolver.Address, 0)
-	endpoints := make(map[string]struct{})
+	// 先过滤出有可用地址的实例，再划分子集，避免子集中的实例没有对应协议的地址
+	epts := make(map[*transport.ServiceInfo]string, len(ins))
+	usable := make([]*transport.ServiceInfo, 0, len(ins))
 	for _, in := range ins {
 		ept, err := endpoint.ParseEndpoint(in.Endpoints, endpoint.Scheme("grpc", !r.insecure))
 		if err != nil {
@@ -167,6 +171,13 @@ func (r *discoveryResolver) update(ins []*transport.ServiceInfo) {
 		if ept == "" {
 			continue
 		}
+		epts[in] = ept
+		usable = append(usable, in)
+	}
+	addrs := make([]resolver.Address, 0)
+	endpoints := make(map[string]struct{})
+	for _, in := range r.subset.Subset(usable) {
+		ept := epts[in]
 		// filter redundant endpoints
 		if _, ok := endpoints[ept]; ok {
 			continue
@@ -27,6 +27,7 @@ import (
 	"google.golang.org/grpc/reflection"
 	"net"
 	"net/url"
+	"sync/atomic"
 )
 
 const (
@@ -35,6 +36,7 @@ const (
 
 var (
 	_ transport.Transport = (*Server)(nil)
+	_ transport.Drainer   = (*Server)(nil)
 )
 
 // Server Grpc服务
@@ -45,6 +47,7 @@ type Server struct {
 	opts        *ServerOptions  // 配置信息
 	listener    net.Listener    // 服务监听器
 	health      *health.Server  // 健康服务
+	inflight    atomic.Int64    // 处理中的请求数
 }
 
 // NewServer 新建
@@ -108,6 +111,16 @@ func (s *Server) Endpoint() (*url.URL, error) {
 	return s.endpoint, nil
 }
 
+// Inflight 处理中的请求数
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
+// MiddlewareRules 按执行顺序返回中间件规则，全局中间件作为第一条无选择器的规则返回
+func (s *Server) MiddlewareRules() []MiddlewareRule {
+	return s.opts.middleware.Rules()
+}
+
 // Start 启动服务
 func (s *Server) Start(ctx context.Context) error {
 	if err := s.listenAndEndpoint(); err != nil {
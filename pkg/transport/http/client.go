@@ -21,11 +21,14 @@ import (
 	"github.com/go-ceres/ceres/internal/bytesconv"
 	"github.com/go-ceres/ceres/pkg/common/errors"
 	"github.com/go-ceres/ceres/pkg/transport"
+	_ "github.com/go-ceres/ceres/pkg/transport/balancer"
+	"github.com/go-ceres/ceres/pkg/transport/retry"
 	"github.com/valyala/fasthttp"
+	"io"
 	"net"
-	"strconv"
-	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 var (
@@ -84,7 +87,8 @@ type Client struct {
 	insecure bool
 	resolver *resolver
 	selector transport.Selector
-	cc       *fasthttp.HostClient
+	retrier  *retry.Retrier
+	cc       *fasthttp.Client // 按请求地址的主机维护连接池，每次尝试连接到选中的节点
 }
 
 // NewClient 创建客户端
@@ -107,23 +111,46 @@ func NewClientWithOptions(options *ClientOptions) (*Client, error) {
 	var selector transport.Selector
 	// 如果有复制均衡
 	if options.discovery != nil {
-		selector = transport.GetSelectorBuilder().Build()
-		resolver, err = newResolver(options.ctx, options.logger, options.discovery, target, selector, options.Block, insecure)
+		if selector, err = transport.BuildSelectorWithOutlier(options.Balancer, options.Outlier); err != nil {
+			return nil, err
+		}
+		resolver, err = newResolver(options.ctx, options.logger, options.discovery, target, selector, options.Subset, options.Block, insecure)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var retrier *retry.Retrier
+	if options.Retry != nil && options.Retry.MaxAttempts > 1 {
+		retrier = options.Retry.Build()
 	}
 	return &Client{
 		target:   target,
 		insecure: insecure,
 		resolver: resolver,
 		selector: selector,
+		retrier:  retrier,
 		options:  options,
-		cc: &fasthttp.HostClient{
+		cc: &fasthttp.Client{
 			TLSConfig: options.TlsConf,
-			IsTLS:     !insecure,
 			Name:      options.UserAgent,
 		},
 	}, nil
 }
 
+// Close 关闭客户端，停止服务发现，选择器实现 io.Closer 时一并关闭
+func (c *Client) Close() error {
+	var err error
+	if c.resolver != nil {
+		err = c.resolver.Close()
+	}
+	if closer, ok := c.selector.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 // Invoke ...
 func (c *Client) Invoke(ctx context.Context, method, path string, args interface{}, reply interface{}, opts ...CallOption) (err error) {
 	// 解码出request信息
@@ -203,7 +230,7 @@ func (c *Client) Invoke(ctx context.Context, method, path string, args interface
 
 func (c *Client) invoke(ctx context.Context, req *Request, resp *Response, args interface{}, reply interface{}, info *callInfo, opts ...CallOption) error {
 	h := func(ctx context.Context, in interface{}) (interface{}, error) {
-		err := c.do(ctx, req, resp)
+		err := c.send(ctx, req, resp)
 		if err != nil {
 			return nil, err
 		}
@@ -218,6 +245,11 @@ func (c *Client) invoke(ctx context.Context, req *Request, resp *Response, args
 		}
 		return reply, nil
 	}
+	if c.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Timeout)
+		defer cancel()
+	}
 	var p transport.Peer
 	ctx = transport.NewPeerContext(ctx, &p)
 	if len(c.options.middleware) > 0 {
@@ -235,7 +267,27 @@ func (c *Client) Do(ctx context.Context, req *Request, resp *Response, opts ...C
 			return err
 		}
 	}
-	return c.do(ctx, req, resp)
+	return c.send(ctx, req, resp)
+}
+
+// send 发送请求，配置了重试策略且请求允许重试时按策略重试，每次尝试都会重新选择节点
+func (c *Client) send(ctx context.Context, req *Request, resp *Response) error {
+	if c.retrier == nil || !c.options.retryIf(req) {
+		return c.do(ctx, req, resp)
+	}
+	reply, err := c.retrier.Do(ctx, func(ctx context.Context, attempt int) (interface{}, error) {
+		if !c.retrier.Hedging() {
+			return resp, c.do(ctx, req, resp)
+		}
+		// 对冲请求会并发执行，每次尝试使用独立的请求与响应
+		attemptReq, attemptResp := &Request{}, &Response{}
+		req.CopyTo(attemptReq)
+		return attemptResp, c.do(ctx, attemptReq, attemptResp)
+	})
+	if r, ok := reply.(*Response); ok && r != resp {
+		r.CopyTo(resp)
+	}
+	return err
 }
 
 func (c *Client) do(ctx context.Context, req *Request, resp *Response) error {
@@ -256,10 +308,23 @@ func (c *Client) do(ctx context.Context, req *Request, resp *Response) error {
 		req.URI().SetHost(node.Address())
 		req.SetHost(node.Address())
 	}
-	c.cc.Addr = addMissingPort(bytesconv.BytesToString(req.URI().Host()), !c.insecure)
-	err := c.cc.Do(req, resp)
+	var err error
+	if deadline, ok := ctx.Deadline(); ok {
+		// 将剩余超时时间传递给服务端
+		if remaining := time.Until(deadline); remaining > 0 {
+			req.Header.Set(HeaderTimeout, encodeTimeout(remaining))
+			err = c.cc.DoDeadline(req, resp, deadline)
+		} else {
+			err = context.DeadlineExceeded
+		}
+		err = deadlineExceeded(ctx, err)
+	} else {
+		err = c.cc.Do(req, resp)
+	}
 	if err == nil {
 		err = c.options.errorDecoder(ctx, resp)
+	} else {
+		err = connectionError(err)
 	}
 	if done != nil {
 		done(ctx, transport.DoneInfo{Err: err})
@@ -270,14 +335,24 @@ func (c *Client) do(ctx context.Context, req *Request, resp *Response) error {
 	return nil
 }
 
-func addMissingPort(addr string, isTLS bool) string {
-	n := strings.Index(addr, ":")
-	if n >= 0 {
-		return addr
-	}
-	port := 80
-	if isTLS {
-		port = 443
+// connectionError 将建立连接失败、连接被拒绝或被关闭等连接级错误转换为503，使其按默认策略重试并计入节点失败
+func connectionError(err error) error {
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, fasthttp.ErrDialTimeout),
+		errors.Is(err, fasthttp.ErrConnectionClosed),
+		errors.Is(err, fasthttp.ErrNoFreeConns),
+		errors.Is(err, fasthttp.ErrTLSHandshakeTimeout),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &opErr) && opErr.Op == "dial":
+		return errors.ServiceUnavailable("CONNECTION_ERROR", err.Error()).WithCause(err)
 	}
-	return net.JoinHostPort(addr, strconv.Itoa(port))
+	return err
+}
+
+// isIdempotent 默认只重试幂等请求
+func isIdempotent(req *Request) bool {
+	return req.Header.IsGet() || req.Header.IsHead() || req.Header.IsPut() ||
+		req.Header.IsDelete() || req.Header.IsOptions() || req.Header.IsTrace()
 }
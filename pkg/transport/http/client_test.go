@@ -17,6 +17,10 @@ package http
 
 import (
 	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/transport/retry"
+	"github.com/valyala/fasthttp"
+	"net"
 	"testing"
 )
 
@@ -60,3 +64,26 @@ func TestClient(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestConnectionError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	req, resp := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+	req.SetRequestURI("http://" + addr + "/")
+	err = connectionError((&fasthttp.Client{}).Do(req, resp))
+	if !errors.IsServiceUnavailable(err) || errors.Reason(err) != "CONNECTION_ERROR" {
+		t.Fatalf("want connection error as 503, got %v", err)
+	}
+	if !retry.DefaultOptions().Retryable(err) {
+		t.Fatal("connection errors should be retryable by default")
+	}
+	if err = connectionError(errors.BadRequest("BAD_REQUEST", "bad")); errors.IsServiceUnavailable(err) {
+		t.Fatal("non connection errors should be kept")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"github.com/go-ceres/ceres/internal/bytesconv"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/valyala/fasthttp"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 支持的压缩编码
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+)
+
+// CompressOptions 压缩配置，响应压缩按 Accept-Encoding 协商，请求体按 Content-Encoding 解压
+type CompressOptions struct {
+	Enable       bool     `json:"enable"`       // 是否开启响应压缩，默认值：false
+	Decompress   bool     `json:"decompress"`   // 是否解压请求体，解压后的大小受 MaxRequestBodySize 限制，默认值：false
+	MinLength    int      `json:"minLength"`    // 响应体小于该长度时不压缩，默认值：1024
+	Encodings    []string `json:"encodings"`    // 支持的编码，按优先级排序，默认值：br,gzip,deflate
+	ContentTypes []string `json:"contentTypes"` // 允许压缩的响应类型，支持 text/* 与 application/*+json 形式的通配
+	GzipLevel    int      `json:"gzipLevel"`    // gzip压缩级别，默认值：6
+	DeflateLevel int      `json:"deflateLevel"` // deflate压缩级别，默认值：6
+	BrotliLevel  int      `json:"brotliLevel"`  // brotli压缩级别，默认值：4
+}
+
+// DefaultCompressOptions 默认压缩配置
+func DefaultCompressOptions() *CompressOptions {
+	return &CompressOptions{
+		MinLength: 1024,
+		Encodings: []string{EncodingBrotli, EncodingGzip, EncodingDeflate},
+		ContentTypes: []string{
+			"text/*",
+			MIMEApplicationJSON,
+			MIMEApplicationXML,
+			MIMEApplicationJavaScript,
+			"application/x-protobuf",
+			"application/*+json",
+			"application/*+xml",
+			"image/svg+xml",
+		},
+		GzipLevel:    fasthttp.CompressDefaultCompression,
+		DeflateLevel: fasthttp.CompressDefaultCompression,
+		BrotliLevel:  fasthttp.CompressBrotliDefaultCompression,
+	}
+}
+
+// negotiate 根据 Accept-Encoding 选择编码，权重相同时按配置顺序
+func (o *CompressOptions) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if f, err := strconv.ParseFloat(params[2:], 64); err == nil {
+				q = f
+			}
+		}
+		weights[name] = q
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range o.Encodings {
+		q, ok := weights[encoding]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressible 判断响应类型是否允许压缩
+func (o *CompressOptions) compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, pattern := range o.ContentTypes {
+		if i := strings.IndexByte(pattern, '*'); i >= 0 {
+			if len(mediaType) > len(pattern)-1 && strings.HasPrefix(mediaType, pattern[:i]) && strings.HasSuffix(mediaType, pattern[i+1:]) {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// compress 压缩响应体，流式响应、已编码响应以及空响应不压缩
+func (s *Server) compress(ctx *Context) {
+	o := s.opts.Compress
+	if o == nil || !o.Enable || ctx.IsHead() {
+		return
+	}
+	resp := ctx.Response()
+	code := resp.StatusCode()
+	if code < StatusOK || code == StatusNoContent || code == StatusNotModified {
+		return
+	}
+	if resp.IsBodyStream() || len(resp.Header.ContentEncoding()) > 0 {
+		return
+	}
+	body := resp.Body()
+	if len(body) < o.MinLength || !o.compressible(bytesconv.BytesToString(resp.Header.ContentType())) {
+		return
+	}
+	resp.Header.Add(HeaderVary, HeaderAcceptEncoding)
+	var compressed []byte
+	switch o.negotiate(ctx.GetRequestHeader(HeaderAcceptEncoding)) {
+	case EncodingGzip:
+		compressed = fasthttp.AppendGzipBytesLevel(nil, body, o.GzipLevel)
+		resp.Header.SetContentEncoding(EncodingGzip)
+	case EncodingDeflate:
+		compressed = fasthttp.AppendDeflateBytesLevel(nil, body, o.DeflateLevel)
+		resp.Header.SetContentEncoding(EncodingDeflate)
+	case EncodingBrotli:
+		compressed = fasthttp.AppendBrotliBytesLevel(nil, body, o.BrotliLevel)
+		resp.Header.SetContentEncoding(EncodingBrotli)
+	default:
+		return
+	}
+	resp.SetBodyRaw(compressed)
+}
+
+// decompress 解压请求体
+func (s *Server) decompress(ctx *Context) error {
+	o := s.opts.Compress
+	if o == nil || !o.Decompress {
+		return nil
+	}
+	req := ctx.Request()
+	encoding := strings.ToLower(strings.TrimSpace(bytesconv.BytesToString(req.Header.ContentEncoding())))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+	var (
+		r   io.Reader
+		err error
+	)
+	body := bytes.NewReader(req.Body())
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		r, err = gzip.NewReader(body)
+	case EncodingDeflate:
+		r, err = zlib.NewReader(body)
+	case EncodingBrotli:
+		r = brotli.NewReader(body)
+	default:
+		return errors.New(StatusUnsupportedMediaType, "UNSUPPORTED_CONTENT_ENCODING", "unsupported content encoding '"+encoding+"'")
+	}
+	if err != nil {
+		return errors.BadRequest("INVALID_REQUEST_BODY", err.Error())
+	}
+	limit := s.opts.MaxRequestBodySize
+	if limit <= 0 {
+		limit = fasthttp.DefaultMaxRequestBodySize
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return errors.BadRequest("INVALID_REQUEST_BODY", err.Error())
+	}
+	if len(data) > limit {
+		return errors.New(StatusRequestEntityTooLarge, "REQUEST_BODY_TOO_LARGE", "request body exceeds "+strconv.Itoa(limit)+" bytes after decompression")
+	}
+	req.SetBodyRaw(data)
+	req.Header.Del(HeaderContentEncoding)
+	req.Header.SetContentLength(len(data))
+	return nil
+}
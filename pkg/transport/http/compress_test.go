@@ -0,0 +1,108 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/valyala/fasthttp"
+	"strings"
+	"testing"
+)
+
+func TestCompressNegotiate(t *testing.T) {
+	o := DefaultCompressOptions()
+	cases := map[string]string{
+		"":                        "",
+		"gzip":                    EncodingGzip,
+		"gzip, deflate, br":       EncodingBrotli,
+		"br;q=0.5, gzip;q=0.8":    EncodingGzip,
+		"br;q=0, *":               EncodingGzip,
+		"identity":                "",
+		"deflate;q=1.0, gzip;q=1": EncodingGzip,
+	}
+	for accept, want := range cases {
+		if got := o.negotiate(accept); got != want {
+			t.Fatalf("accept %q: want %q, got %q", accept, want, got)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"ceres"}`, 200)
+	o := DefaultCompressOptions()
+	o.Enable = true
+	o.Decompress = true
+	srv := NewServer(WithServerCompress(o))
+	srv.GET("/large", func(ctx *Context) error {
+		ctx.SetResponseHeader(HeaderContentType, MIMEApplicationJSON)
+		return ctx.SendString(large)
+	})
+	srv.GET("/small", func(ctx *Context) error {
+		ctx.SetResponseHeader(HeaderContentType, MIMEApplicationJSON)
+		return ctx.SendString("{}")
+	})
+	srv.GET("/binary", func(ctx *Context) error {
+		ctx.SetResponseHeader(HeaderContentType, "application/octet-stream")
+		return ctx.SendString(large)
+	})
+	srv.POST("/echo", func(ctx *Context) error {
+		_, err := ctx.Write(ctx.Request().Body())
+		return err
+	})
+
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingBrotli} {
+		resp := serveRequest(srv, MethodGet, "/large", map[string]string{HeaderAcceptEncoding: encoding})
+		if got := string(resp.Header.ContentEncoding()); got != encoding {
+			t.Fatalf("want content encoding %s, got %q", encoding, got)
+		}
+		body, err := resp.BodyUncompressed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != large || len(resp.Body()) >= len(large) {
+			t.Fatalf("%s: unexpected body, compressed %d bytes", encoding, len(resp.Body()))
+		}
+	}
+	for _, path := range []string{"/small", "/binary"} {
+		resp := serveRequest(srv, MethodGet, path, map[string]string{HeaderAcceptEncoding: EncodingGzip})
+		if len(resp.Header.ContentEncoding()) != 0 {
+			t.Fatalf("%s should not be compressed", path)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(large))
+	_ = w.Close()
+	var fastCtx fasthttp.RequestCtx
+	fastCtx.Request.Header.SetMethod(MethodPost)
+	fastCtx.Request.SetRequestURI("/echo")
+	fastCtx.Request.Header.Set(HeaderContentEncoding, EncodingGzip)
+	fastCtx.Request.SetBody(buf.Bytes())
+	srv.handler(&fastCtx)
+	if got := string(fastCtx.Response.Body()); got != large {
+		t.Fatalf("want decompressed request body, got %d bytes", len(got))
+	}
+
+	fastCtx.Request.Header.Set(HeaderContentEncoding, "compress")
+	fastCtx.Request.SetBody([]byte("x"))
+	fastCtx.Response.Reset()
+	srv.handler(&fastCtx)
+	if fastCtx.Response.StatusCode() != StatusUnsupportedMediaType {
+		t.Fatalf("want status 415, got %d", fastCtx.Response.StatusCode())
+	}
+}
@@ -49,6 +49,7 @@ type Context struct {
 	pathOriginal string
 	server       *Server
 	pathTemplate string
+	timeoutCtx   context.Context // 带处理超时的上下文
 }
 
 // acquireContext 借用
@@ -75,6 +76,7 @@ func (s *Server) releaseContext(c *Context) {
 	c.pathTemplate = ""
 	c.middleware = nil
 	c.pathOriginal = ""
+	c.timeoutCtx = nil
 	contextPool.Put(c)
 }
 
@@ -153,9 +155,9 @@ func (ctx *Context) MultipartFormValue(key string) (string, bool) {
 func (ctx *Context) Middleware(h transport.Handler) transport.Handler {
 	tr, ok := transport.MetadataFromServerContext(ctx.UserContext())
 	if ok {
-		return transport.MiddlewareChain(ctx.middleware.Match(tr.Operation())...)(h)
+		return transport.MiddlewareChain(ctx.middleware.MatchMethod(ctx.method, tr.Operation(), ctx.pathTemplate)...)(h)
 	}
-	return transport.MiddlewareChain(ctx.middleware.Match(ctx.Path())...)(h)
+	return transport.MiddlewareChain(ctx.middleware.MatchMethod(ctx.method, ctx.Path())...)(h)
 }
 
 func (ctx *Context) SetUserContext(c context.Context) {
@@ -372,10 +374,14 @@ func (ctx *Context) File(path string) error {
 	return nil
 }
 
+// Deadline 请求处理的截止时间，设置了超时时间时返回超时时间
 func (ctx *Context) Deadline() (deadline time.Time, ok bool) {
 	if ctx.fastCtx == nil {
 		return time.Time{}, false
 	}
+	if ctx.timeoutCtx != nil {
+		return ctx.timeoutCtx.Deadline()
+	}
 	return ctx.fastCtx.Deadline()
 }
 
@@ -383,6 +389,9 @@ func (ctx *Context) Done() <-chan struct{} {
 	if ctx.fastCtx == nil {
 		return nil
 	}
+	if ctx.timeoutCtx != nil {
+		return ctx.timeoutCtx.Done()
+	}
 	return ctx.fastCtx.Done()
 }
 
@@ -390,6 +399,11 @@ func (ctx *Context) Err() error {
 	if ctx.fastCtx == nil {
 		return context.Canceled
 	}
+	if ctx.timeoutCtx != nil {
+		if err := ctx.timeoutCtx.Err(); err != nil {
+			return err
+		}
+	}
 	return ctx.fastCtx.Err()
 }
 
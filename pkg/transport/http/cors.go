@@ -0,0 +1,310 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/config"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOption 跨域配置方法
+type CORSOption func(o *CORSOptions)
+
+// CORSOptions 跨域配置，在根路由上注册时未注册OPTIONS路由的预检请求也会被响应
+type CORSOptions struct {
+	AllowOrigins         []string          `json:"allowOrigins"`         // 允许的来源，支持 * 与 https://*.example.com 形式的子域名通配，默认：*
+	AllowOriginRegexps   []string          `json:"allowOriginRegexps"`   // 允许的来源正则
+	AllowOriginFunc      func(string) bool `json:"-"`                    // 自定义来源校验
+	AllowMethods         []string          `json:"allowMethods"`         // 允许的方法，默认：GET,POST,PUT,PATCH,DELETE,HEAD
+	AllowHeaders         []string          `json:"allowHeaders"`         // 允许的请求头，* 表示允许预检请求中的所有请求头
+	ExposeHeaders        []string          `json:"exposeHeaders"`        // 允许浏览器读取的响应头
+	AllowCredentials     bool              `json:"allowCredentials"`     // 是否允许携带凭证，开启后不会返回 * 来源
+	MaxAge               time.Duration     `json:"maxAge"`               // 预检结果缓存时间，小于等于0时不返回
+	OptionsPassthrough   bool              `json:"optionsPassthrough"`   // 预检请求处理后是否继续执行后续处理函数
+	OptionsSuccessStatus int               `json:"optionsSuccessStatus"` // 预检请求成功的状态码，默认：204
+}
+
+// DefaultCORSOptions 默认跨域配置
+func DefaultCORSOptions() *CORSOptions {
+	return &CORSOptions{
+		AllowOrigins:         []string{"*"},
+		AllowMethods:         []string{MethodGet, MethodPost, MethodPut, MethodPatch, MethodDelete, MethodHead},
+		AllowHeaders:         []string{HeaderOrigin, HeaderContentType, HeaderAuthorization},
+		OptionsSuccessStatus: StatusNoContent,
+	}
+}
+
+// ScanCORSRawConfig 扫描无封装key
+func ScanCORSRawConfig(key string) *CORSOptions {
+	conf := DefaultCORSOptions()
+	if err := config.Get(key).Scan(conf); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+// ScanCORSConfig 扫描配置
+func ScanCORSConfig(name ...string) *CORSOptions {
+	key := "application.transport.http.server.cors"
+	if len(name) > 0 {
+		key = key + "." + name[0]
+	}
+	return ScanCORSRawConfig(key)
+}
+
+// WithCORSAllowOrigins 设置允许的来源
+func WithCORSAllowOrigins(origins ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowOrigins = origins
+	}
+}
+
+// WithCORSAllowOriginRegexps 设置允许的来源正则
+func WithCORSAllowOriginRegexps(exprs ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowOriginRegexps = exprs
+	}
+}
+
+// WithCORSAllowOriginFunc 设置自定义来源校验
+func WithCORSAllowOriginFunc(fn func(origin string) bool) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowOriginFunc = fn
+	}
+}
+
+// WithCORSAllowMethods 设置允许的方法
+func WithCORSAllowMethods(methods ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowMethods = methods
+	}
+}
+
+// WithCORSAllowHeaders 设置允许的请求头
+func WithCORSAllowHeaders(headers ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowHeaders = headers
+	}
+}
+
+// WithCORSExposeHeaders 设置允许浏览器读取的响应头
+func WithCORSExposeHeaders(headers ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.ExposeHeaders = headers
+	}
+}
+
+// WithCORSAllowCredentials 设置是否允许携带凭证
+func WithCORSAllowCredentials(allow bool) CORSOption {
+	return func(o *CORSOptions) {
+		o.AllowCredentials = allow
+	}
+}
+
+// WithCORSMaxAge 设置预检结果缓存时间
+func WithCORSMaxAge(maxAge time.Duration) CORSOption {
+	return func(o *CORSOptions) {
+		o.MaxAge = maxAge
+	}
+}
+
+// WithCORSOptionsPassthrough 设置预检请求处理后是否继续执行后续处理函数
+func WithCORSOptionsPassthrough(passthrough bool) CORSOption {
+	return func(o *CORSOptions) {
+		o.OptionsPassthrough = passthrough
+	}
+}
+
+// WithOptions 手动设置参数
+func (o *CORSOptions) WithOptions(opts ...CORSOption) *CORSOptions {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建跨域处理函数
+func (o *CORSOptions) Build() HandlerFunc {
+	return newCors(o).handle
+}
+
+// CORS 跨域处理函数，通过 RouterGroup.Use 使用
+func CORS(opts ...CORSOption) HandlerFunc {
+	return DefaultCORSOptions().WithOptions(opts...).Build()
+}
+
+// cors 跨域处理
+type cors struct {
+	opts            *CORSOptions
+	allowAll        bool
+	origins         map[string]struct{}
+	wildcards       [][2]string
+	regexps         []*regexp.Regexp
+	methods         map[string]struct{}
+	allowHeadersAll bool
+	headers         map[string]struct{}
+	allowMethods    string
+	allowHeaders    string
+	exposeHeaders   string
+	maxAge          string
+}
+
+func newCors(opts *CORSOptions) *cors {
+	c := &cors{
+		opts:          opts,
+		origins:       make(map[string]struct{}),
+		methods:       make(map[string]struct{}),
+		headers:       make(map[string]struct{}),
+		exposeHeaders: strings.Join(opts.ExposeHeaders, ", "),
+	}
+	for _, origin := range opts.AllowOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			c.allowAll = true
+		} else if i := strings.IndexByte(origin, '*'); i >= 0 {
+			c.wildcards = append(c.wildcards, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			c.origins[origin] = struct{}{}
+		}
+	}
+	for _, expr := range opts.AllowOriginRegexps {
+		c.regexps = append(c.regexps, regexp.MustCompile(expr))
+	}
+	methods := make([]string, 0, len(opts.AllowMethods))
+	for _, method := range opts.AllowMethods {
+		method = strings.ToUpper(method)
+		c.methods[method] = struct{}{}
+		methods = append(methods, method)
+	}
+	c.allowMethods = strings.Join(methods, ", ")
+	for _, header := range opts.AllowHeaders {
+		if header == "*" {
+			c.allowHeadersAll = true
+			continue
+		}
+		c.headers[strings.ToLower(header)] = struct{}{}
+	}
+	c.allowHeaders = strings.Join(opts.AllowHeaders, ", ")
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.FormatInt(int64(opts.MaxAge/time.Second), 10)
+	}
+	if opts.OptionsSuccessStatus == 0 {
+		opts.OptionsSuccessStatus = StatusNoContent
+	}
+	return c
+}
+
+// handle 处理跨域请求
+func (c *cors) handle(ctx *Context) error {
+	origin := ctx.GetRequestHeader(HeaderOrigin)
+	if ctx.IsOptions() && ctx.GetRequestHeader(HeaderAccessControlRequestMethod) != "" {
+		return c.preflight(ctx, origin)
+	}
+	if origin == "" {
+		return ctx.Next()
+	}
+	ctx.Response().Header.Add(HeaderVary, HeaderOrigin)
+	if c.isOriginAllowed(origin) {
+		c.setOrigin(ctx, origin)
+		if c.exposeHeaders != "" {
+			ctx.SetResponseHeader(HeaderAccessControlExposeHeaders, c.exposeHeaders)
+		}
+	}
+	return ctx.Next()
+}
+
+// preflight 处理预检请求，不允许的预检请求直接返回403
+func (c *cors) preflight(ctx *Context, origin string) error {
+	header := &ctx.Response().Header
+	header.Add(HeaderVary, HeaderOrigin)
+	header.Add(HeaderVary, HeaderAccessControlRequestMethod)
+	header.Add(HeaderVary, HeaderAccessControlRequestHeaders)
+	if origin == "" || !c.isOriginAllowed(origin) {
+		return errors.Forbidden("CORS_ORIGIN_NOT_ALLOWED", "origin '"+origin+"' is not allowed")
+	}
+	method := strings.ToUpper(ctx.GetRequestHeader(HeaderAccessControlRequestMethod))
+	if _, ok := c.methods[method]; !ok && method != MethodOptions {
+		return errors.Forbidden("CORS_METHOD_NOT_ALLOWED", "method '"+method+"' is not allowed")
+	}
+	reqHeaders := ctx.GetRequestHeader(HeaderAccessControlRequestHeaders)
+	if !c.allowHeadersAll {
+		for _, h := range strings.Split(reqHeaders, ",") {
+			h = strings.ToLower(strings.TrimSpace(h))
+			if h == "" {
+				continue
+			}
+			if _, ok := c.headers[h]; !ok {
+				return errors.Forbidden("CORS_HEADER_NOT_ALLOWED", "header '"+h+"' is not allowed")
+			}
+		}
+	}
+	c.setOrigin(ctx, origin)
+	ctx.SetResponseHeader(HeaderAccessControlAllowMethods, c.allowMethods)
+	if c.allowHeadersAll && reqHeaders != "" {
+		ctx.SetResponseHeader(HeaderAccessControlAllowHeaders, reqHeaders)
+	} else if !c.allowHeadersAll && c.allowHeaders != "" {
+		ctx.SetResponseHeader(HeaderAccessControlAllowHeaders, c.allowHeaders)
+	}
+	if c.maxAge != "" {
+		ctx.SetResponseHeader(HeaderAccessControlMaxAge, c.maxAge)
+	}
+	if c.opts.OptionsPassthrough {
+		return ctx.Next()
+	}
+	ctx.SetStatusCode(c.opts.OptionsSuccessStatus)
+	return nil
+}
+
+// setOrigin 设置允许的来源与凭证
+func (c *cors) setOrigin(ctx *Context, origin string) {
+	if c.allowAll && !c.opts.AllowCredentials {
+		ctx.SetResponseHeader(HeaderAccessControlAllowOrigin, "*")
+	} else {
+		ctx.SetResponseHeader(HeaderAccessControlAllowOrigin, origin)
+	}
+	if c.opts.AllowCredentials {
+		ctx.SetResponseHeader(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// isOriginAllowed 校验来源，依次匹配全部、精确、通配、正则与自定义函数
+func (c *cors) isOriginAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if _, ok := c.origins[lower]; ok {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, re := range c.regexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	if c.opts.AllowOriginFunc != nil {
+		return c.opts.AllowOriginFunc(origin)
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"github.com/valyala/fasthttp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveCORS 直接调用服务入口处理请求
+func serveCORS(srv *Server, method, path string, headers map[string]string) *fasthttp.Response {
+	var fastCtx fasthttp.RequestCtx
+	fastCtx.Request.Header.SetMethod(method)
+	fastCtx.Request.SetRequestURI(path)
+	for k, v := range headers {
+		fastCtx.Request.Header.Set(k, v)
+	}
+	srv.handler(&fastCtx)
+	resp := &fasthttp.Response{}
+	fastCtx.Response.CopyTo(resp)
+	return resp
+}
+
+func TestCORS(t *testing.T) {
+	srv := NewServer()
+	srv.Use(CORS(
+		WithCORSAllowOrigins("https://example.com", "https://*.example.org"),
+		WithCORSAllowOriginRegexps(`^https://[a-z]+\.example\.net$`),
+		WithCORSAllowOriginFunc(func(origin string) bool { return origin == "https://custom.io" }),
+		WithCORSAllowHeaders("Content-Type", "X-Token"),
+		WithCORSExposeHeaders("X-Request-Id"),
+		WithCORSAllowCredentials(true),
+		WithCORSMaxAge(10*time.Minute),
+	))
+	srv.GET("/users/:id", func(ctx *Context) error {
+		return ctx.SendString("ok")
+	})
+
+	for _, origin := range []string{"https://example.com", "https://a.example.org", "https://api.example.net", "https://custom.io"} {
+		resp := serveCORS(srv, MethodGet, "/users/1", map[string]string{HeaderOrigin: origin})
+		if got := string(resp.Header.Peek(HeaderAccessControlAllowOrigin)); got != origin {
+			t.Fatalf("origin %s: want allow origin %s, got %q", origin, origin, got)
+		}
+		if got := string(resp.Header.Peek(HeaderAccessControlAllowCredentials)); got != "true" {
+			t.Fatalf("want credentials true, got %q", got)
+		}
+		if got := string(resp.Header.Peek(HeaderAccessControlExposeHeaders)); got != "X-Request-Id" {
+			t.Fatalf("want expose headers, got %q", got)
+		}
+	}
+
+	resp := serveCORS(srv, MethodGet, "/users/1", map[string]string{HeaderOrigin: "https://example.org"})
+	if resp.StatusCode() != StatusOK || len(resp.Header.Peek(HeaderAccessControlAllowOrigin)) != 0 {
+		t.Fatalf("disallowed origin should pass without cors headers, got %d %q", resp.StatusCode(), resp.Header.Peek(HeaderAccessControlAllowOrigin))
+	}
+
+	// 未注册OPTIONS路由的预检请求
+	resp = serveCORS(srv, MethodOptions, "/users/1", map[string]string{
+		HeaderOrigin:                      "https://example.com",
+		HeaderAccessControlRequestMethod:  MethodPut,
+		HeaderAccessControlRequestHeaders: "content-type, x-token",
+	})
+	if resp.StatusCode() != StatusNoContent {
+		t.Fatalf("want preflight status 204, got %d", resp.StatusCode())
+	}
+	if got := string(resp.Header.Peek(HeaderAccessControlAllowMethods)); !strings.Contains(got, MethodPut) {
+		t.Fatalf("want allow methods contains PUT, got %q", got)
+	}
+	if got := string(resp.Header.Peek(HeaderAccessControlAllowHeaders)); got != "Content-Type, X-Token" {
+		t.Fatalf("want allow headers, got %q", got)
+	}
+	if got := string(resp.Header.Peek(HeaderAccessControlMaxAge)); got != "600" {
+		t.Fatalf("want max age 600, got %q", got)
+	}
+
+	for _, headers := range []map[string]string{
+		{HeaderOrigin: "https://evil.com", HeaderAccessControlRequestMethod: MethodGet},
+		{HeaderOrigin: "https://example.com", HeaderAccessControlRequestMethod: MethodConnect},
+		{HeaderOrigin: "https://example.com", HeaderAccessControlRequestMethod: MethodGet, HeaderAccessControlRequestHeaders: "X-Other"},
+	} {
+		if resp = serveCORS(srv, MethodOptions, "/users/1", headers); resp.StatusCode() != StatusForbidden {
+			t.Fatalf("want preflight status 403, got %d", resp.StatusCode())
+		}
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	srv := NewServer()
+	srv.Use(CORS(WithCORSAllowHeaders("*")))
+	srv.POST("/users", func(ctx *Context) error {
+		return ctx.SendString("ok")
+	})
+	resp := serveCORS(srv, MethodPost, "/users", map[string]string{HeaderOrigin: "https://any.com"})
+	if got := string(resp.Header.Peek(HeaderAccessControlAllowOrigin)); got != "*" {
+		t.Fatalf("want allow origin *, got %q", got)
+	}
+	resp = serveCORS(srv, MethodOptions, "/users", map[string]string{
+		HeaderOrigin:                      "https://any.com",
+		HeaderAccessControlRequestMethod:  MethodPost,
+		HeaderAccessControlRequestHeaders: "X-Anything",
+	})
+	if resp.StatusCode() != StatusNoContent || string(resp.Header.Peek(HeaderAccessControlAllowHeaders)) != "X-Anything" {
+		t.Fatalf("want echoed allow headers, got %d %q", resp.StatusCode(), resp.Header.Peek(HeaderAccessControlAllowHeaders))
+	}
+}
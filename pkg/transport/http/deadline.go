@@ -0,0 +1,77 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"time"
+)
+
+// HeaderTimeout 请求剩余的超时时间，单位毫秒，客户端根据上下文截止时间写入，服务端据此设置处理超时
+const HeaderTimeout = "X-Ceres-Timeout"
+
+// encodeTimeout 编码超时时间，不足1毫秒按1毫秒计算
+func encodeTimeout(timeout time.Duration) string {
+	ms := int64(timeout / time.Millisecond)
+	if ms <= 0 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
+
+// decodeTimeout 解码超时时间
+func decodeTimeout(s string) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// timeout 计算请求的处理超时时间，取路由超时(未配置时使用全局超时)与上游剩余超时时间的最小值
+func (s *Server) timeout(ctx *Context, pathTemplate string) time.Duration {
+	timeout := s.opts.Timeout
+	if t, ok := s.opts.RouteTimeouts[ctx.method+" "+pathTemplate]; ok {
+		timeout = t
+	} else if t, ok := s.opts.RouteTimeouts[pathTemplate]; ok {
+		timeout = t
+	}
+	if t, ok := decodeTimeout(ctx.GetRequestHeader(HeaderTimeout)); ok && (timeout <= 0 || t < timeout) {
+		timeout = t
+	}
+	return timeout
+}
+
+// deadlineExceeded 超时相关的错误统一转换为网关超时错误，包括下游grpc返回的 DeadlineExceeded，
+// 已经是 *errors.Error 的错误保留原有状态码
+func deadlineExceeded(ctx context.Context, err error) error {
+	if err == nil || errors.As(err, new(*errors.Error)) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, fasthttp.ErrTimeout) ||
+		ctx.Err() == context.DeadlineExceeded || status.Code(err) == codes.DeadlineExceeded {
+		return errors.GatewayTimeout("GATEWAY_TIMEOUT", "request deadline exceeded").WithCause(err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newDeadlineServer(t *testing.T, opts ...ServerOption) (*Client, chan time.Duration) {
+	ln := fasthttputil.NewInmemoryListener()
+	srv := NewServer(opts...)
+	srv.listener = ln
+	srv.endpoint = &url.URL{Scheme: "http", Host: "127.0.0.1:5200"}
+	remaining := make(chan time.Duration, 1)
+	srv.GET("/slow", func(ctx *Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			remaining <- 0
+			return errors.InternalServer("NO_DEADLINE", "")
+		}
+		remaining <- time.Until(deadline)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+	client, err := NewClient(WithClientEndpoint("http://127.0.0.1:5200"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.cc.Dial = func(addr string) (net.Conn, error) {
+		return ln.Dial()
+	}
+	return client, remaining
+}
+
+func doSlow(ctx context.Context, client *Client) error {
+	req, resp := AcquireRequest(), AcquireResponse()
+	defer ReleaseRequest(req)
+	defer ReleaseResponse(resp)
+	req.SetRequestURI("http://127.0.0.1:5200/slow")
+	req.Header.SetMethod(MethodGet)
+	return client.Do(ctx, req, resp)
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	client, remaining := newDeadlineServer(t, WithServerTimeout(time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := doSlow(ctx, client)
+	if !errors.IsGatewayTimeout(err) {
+		t.Fatalf("want gateway timeout, got %v", err)
+	}
+	if d := <-remaining; d <= 0 || d > 100*time.Millisecond {
+		t.Fatalf("server deadline must follow the client deadline, got %v", d)
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	client, remaining := newDeadlineServer(t,
+		WithServerTimeout(time.Second),
+		WithServerRouteTimeout("GET /slow", 50*time.Millisecond),
+	)
+	start := time.Now()
+	err := doSlow(context.Background(), client)
+	if !errors.IsGatewayTimeout(err) {
+		t.Fatalf("want gateway timeout, got %v", err)
+	}
+	if d := <-remaining; d <= 0 || d > 50*time.Millisecond {
+		t.Fatalf("want route timeout, got %v", d)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("route timeout not applied")
+	}
+}
+
+func TestDeadlineExceededError(t *testing.T) {
+	ctx := context.Background()
+	if err := deadlineExceeded(ctx, status.Error(codes.DeadlineExceeded, "timeout")); !errors.IsGatewayTimeout(err) {
+		t.Fatalf("grpc deadline exceeded want gateway timeout, got %v", err)
+	}
+	if err := deadlineExceeded(ctx, errors.BadRequest("BAD", "bad")); !errors.IsBadRequest(err) {
+		t.Fatalf("ceres error must keep its code, got %v", err)
+	}
+	if err := deadlineExceeded(ctx, status.Error(codes.Unavailable, "down")); errors.IsGatewayTimeout(err) {
+		t.Fatalf("unexpected gateway timeout %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"google.golang.org/grpc"
+	"sort"
+)
+
+// GRPCServiceInfo grpc服务信息，grpc.Server 与 transport/grpc.Server 均已实现
+type GRPCServiceInfo interface {
+	GetServiceInfo() map[string]grpc.ServiceInfo
+}
+
+// middlewareRuler 可以返回中间件规则的服务
+type middlewareRuler interface {
+	MiddlewareRules() []MiddlewareRule
+}
+
+type debugInfo struct {
+	HTTP debugHTTP   `json:"http"`
+	GRPC []debugGRPC `json:"grpc,omitempty"`
+}
+
+type debugHTTP struct {
+	Routes     []RouteInfo `json:"routes"`
+	Middleware []debugRule `json:"middleware"`
+}
+
+type debugGRPC struct {
+	Services   []debugService `json:"services"`
+	Middleware []debugRule    `json:"middleware,omitempty"`
+}
+
+type debugService struct {
+	Name     string        `json:"name"`
+	Methods  []debugMethod `json:"methods"`
+	Metadata interface{}   `json:"metadata,omitempty"`
+}
+
+type debugMethod struct {
+	Name            string `json:"name"`
+	FullMethod      string `json:"fullMethod"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+}
+
+type debugRule struct {
+	Selectors  []string `json:"selectors,omitempty"`
+	Excludes   []string `json:"excludes,omitempty"`
+	Priority   int      `json:"priority"`
+	Middleware int      `json:"middleware"`
+}
+
+// Debug 注册调试接口，返回http路由与中间件规则，以及传入的grpc服务的方法列表与中间件规则，
+// 用于排查路由未命中等问题，生产环境建议配合鉴权中间件使用
+func (group *RouterGroup) Debug(relativePath string, servers ...GRPCServiceInfo) IRoutes {
+	s := group.server
+	return group.GET(relativePath, func(ctx *Context) error {
+		info := debugInfo{HTTP: debugHTTP{
+			Routes:     s.Routes(),
+			Middleware: debugRules(s.MiddlewareRules()),
+		}}
+		for _, srv := range servers {
+			info.GRPC = append(info.GRPC, debugGRPCInfo(srv))
+		}
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		ctx.SetResponseHeader(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+		_, err = ctx.Write(data)
+		return err
+	})
+}
+
+// debugGRPCInfo 获取grpc服务信息
+func debugGRPCInfo(srv GRPCServiceInfo) debugGRPC {
+	var info debugGRPC
+	for name, svc := range srv.GetServiceInfo() {
+		service := debugService{Name: name, Metadata: svc.Metadata}
+		for _, m := range svc.Methods {
+			service.Methods = append(service.Methods, debugMethod{
+				Name:            m.Name,
+				FullMethod:      "/" + name + "/" + m.Name,
+				ClientStreaming: m.IsClientStream,
+				ServerStreaming: m.IsServerStream,
+			})
+		}
+		info.Services = append(info.Services, service)
+	}
+	sort.Slice(info.Services, func(i, j int) bool {
+		return info.Services[i].Name < info.Services[j].Name
+	})
+	if ruler, ok := srv.(middlewareRuler); ok {
+		info.Middleware = debugRules(ruler.MiddlewareRules())
+	}
+	return info
+}
+
+func debugRules(rules []MiddlewareRule) []debugRule {
+	res := make([]debugRule, 0, len(rules))
+	for _, r := range rules {
+		res = append(res, debugRule{
+			Selectors:  r.Selectors,
+			Excludes:   r.Excludes,
+			Priority:   r.Priority,
+			Middleware: len(r.Middleware),
+		})
+	}
+	return res
+}
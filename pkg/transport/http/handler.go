@@ -18,6 +18,8 @@ package http
 import (
 	"github.com/go-ceres/ceres/internal/httputil"
 	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"net/http"
 )
 
 // HandlerFunc 方法定义
@@ -34,6 +36,15 @@ func (h HandlersChain) Last() HandlerFunc {
 	return nil
 }
 
+// WrapHandler 将标准库的 http.Handler 转换为路由方法
+func WrapHandler(h http.Handler) HandlerFunc {
+	handler := fasthttpadaptor.NewFastHTTPHandler(h)
+	return func(ctx *Context) error {
+		handler(ctx.GetFastCtx())
+		return nil
+	}
+}
+
 // DefaultErrorHandler 默认的错误处理方法
 var DefaultErrorHandler = func(c *Context, err error) error {
 	code := StatusInternalServerError
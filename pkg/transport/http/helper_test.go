@@ -0,0 +1,34 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// serveRequest 直接调用服务入口处理请求
+func serveRequest(srv *Server, method, path string, headers map[string]string) *fasthttp.Response {
+	var fastCtx fasthttp.RequestCtx
+	fastCtx.Request.Header.SetMethod(method)
+	fastCtx.Request.SetRequestURI(path)
+	for k, v := range headers {
+		fastCtx.Request.Header.Set(k, v)
+	}
+	srv.handler(&fastCtx)
+	resp := &fasthttp.Response{}
+	fastCtx.Response.CopyTo(resp)
+	return resp
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/go-ceres/ceres/pkg/common/config"
 	"github.com/go-ceres/ceres/pkg/common/logger"
 	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/go-ceres/ceres/pkg/transport/retry"
 	"time"
 )
 
@@ -38,7 +39,8 @@ type ServerOption func(o *ServerOptions)
 type ServerOptions struct {
 	Network                       string                              `json:"network"`                       // 网络类型
 	Address                       string                              `json:"address"`                       // 连接地址
-	Timeout                       time.Duration                       `json:"timeout"`                       // 超时时间
+	Timeout                       time.Duration                       `json:"timeout"`                       // 请求处理超时时间，与上游传递的剩余超时时间取最小值，默认不限制
+	RouteTimeouts                 map[string]time.Duration            `json:"routeTimeouts"`                 // 按路由覆盖超时时间，键为路由模板或 "方法 路由模板"，如 "GET /user/:id"
 	TlsConf                       *TlsConfig                          `json:"tlsConf"`                       // tls配置
 	AllowedMethods                []string                            `json:"allowed_methods"`               // 允许添加的方法
 	DisablePrintRoute             bool                                `json:"disablePrintRoute"`             // 禁止打印路由
@@ -64,6 +66,9 @@ type ServerOptions struct {
 	RemoveExtraSlash              bool                                `json:"removeExtraSlash"`              // 启用后，即使使用了额外的斜杠也能匹配到路由,默认值为：false
 	RedirectTrailingSlash         bool                                `json:"redirectTrailingSlash"`         // 启用该配置，如果请求了/get/，但只有/get则会重定向到/get 默认值为：true
 	RedirectFixedPath             bool                                `json:"redirectFixedPath"`             // 启用该配置，则会尝试修复路由 默认值为：false
+	WebSocket                     *WebSocketOptions                   `json:"webSocket"`                     // websocket配置
+	SSEHeartbeat                  time.Duration                       `json:"sseHeartbeat"`                  // 事件流心跳注释的发送间隔，小于等于0时不发送，默认值：15s
+	Compress                      *CompressOptions                    `json:"compress"`                      // 响应压缩与请求体解压配置
 	middleware                    matcher.Matcher                     // 中间件
 	JSONCodec                     codec.Codec                         // json编解码器
 	XmlCodec                      codec.Codec                         // xml编解码器
@@ -77,18 +82,23 @@ type ClientOption func(o *ClientOptions)
 
 // ClientOptions 客户端创建参数结构体
 type ClientOptions struct {
-	Debug          bool                   `json:"debug"`     // 是否开启调试模式，默认值为：false
-	Endpoint       string                 `json:"endpoint"`  // 请求地址：默认值为：""
-	Block          bool                   `json:"block"`     // 是否
-	UserAgent      string                 `json:"userAgent"` // user-agent 请求头，默认：""
-	Timeout        time.Duration          `json:"timeout"`   // 请求超时时间，默认值：2s
-	TlsConf        *tls.Config            `json:"tlsConf"`   // tls认证信息，默认值为：nil
-	decodeResponse DecodeResponseFunc     // 响应信息解码器
-	encodeRequest  EncodeRequestFunc      // 请求体编码器
-	errorDecoder   DecodeErrorFunc        // 错误解码器
-	middleware     []transport.Middleware // 中间件
-	nodeFilters    []transport.NodeFilter // 节点过滤器
-	discovery      transport.Discover     // 服务发现
+	Debug          bool                      `json:"debug"`     // 是否开启调试模式，默认值为：false
+	Endpoint       string                    `json:"endpoint"`  // 请求地址：默认值为：""
+	Block          bool                      `json:"block"`     // 是否
+	UserAgent      string                    `json:"userAgent"` // user-agent 请求头，默认：""
+	Timeout        time.Duration             `json:"timeout"`   // 请求超时时间，与上下文截止时间取最小值，剩余时间通过请求头传递给服务端，默认值：2s
+	TlsConf        *tls.Config               `json:"tlsConf"`   // tls认证信息，默认值为：nil
+	Retry          *retry.Options            `json:"retry"`     // 重试策略，默认不重试
+	Balancer       string                    `json:"balancer"`  // 负载均衡器名称，默认为空使用全局选择器
+	Subset         *transport.SubsetOptions  `json:"subset"`    // 实例子集划分，默认不开启
+	Outlier        *transport.OutlierOptions `json:"outlier"`   // 异常节点检测，默认不开启
+	retryIf        RetryIfFunc               // 判断请求是否允许重试，默认只重试幂等请求
+	decodeResponse DecodeResponseFunc        // 响应信息解码器
+	encodeRequest  EncodeRequestFunc         // 请求体编码器
+	errorDecoder   DecodeErrorFunc           // 错误解码器
+	middleware     []transport.Middleware    // 中间件
+	nodeFilters    []transport.NodeFilter    // 节点过滤器
+	discovery      transport.Discover        // 服务发现
 	ctx            context.Context
 	logger         *logger.Logger // 日志组件
 }
@@ -127,6 +137,9 @@ func DefaultServerOptions() *ServerOptions {
 		RemoveExtraSlash:      false,
 		RedirectTrailingSlash: true,
 		RedirectFixedPath:     false,
+		WebSocket:             DefaultWebSocketOptions(),
+		SSEHeartbeat:          15 * time.Second,
+		Compress:              DefaultCompressOptions(),
 		middleware:            matcher.New(),
 		JSONCodec:             codec.LoadCodec("json"),
 		XmlCodec:              codec.LoadCodec("xml"),
@@ -172,6 +185,16 @@ func WithServerTimeout(Timeout time.Duration) ServerOption {
 	}
 }
 
+// WithServerRouteTimeout 设置路由的超时时间，覆盖全局超时时间，route 为路由模板或 "方法 路由模板"
+func WithServerRouteTimeout(route string, timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		if o.RouteTimeouts == nil {
+			o.RouteTimeouts = make(map[string]time.Duration)
+		}
+		o.RouteTimeouts[route] = timeout
+	}
+}
+
 func WithServerTlsConf(TlsConf *TlsConfig) ServerOption {
 	return func(o *ServerOptions) {
 		o.TlsConf = TlsConf
@@ -316,12 +339,27 @@ func WithServerMiddleware(middlewares ...transport.Middleware) ServerOption {
 	}
 }
 
+// AddServerMiddleware 按选择器添加中间件，选择器同时匹配操作名与路由模板，支持：
+//   - 精确匹配与通配符：/user/:id、/helloworld.Greeter/*
+//   - 正则：regex:^/api/v\d+/
+//   - 请求方法：GET /user/*
+//   - 排除：!/health,!/login 表示除这两个路由之外的全部请求
 func AddServerMiddleware(selector string, middlewares ...transport.Middleware) ServerOption {
 	return func(o *ServerOptions) {
 		o.middleware.Add(selector, middlewares...)
 	}
 }
 
+// MiddlewareRule 中间件匹配规则
+type MiddlewareRule = matcher.Rule
+
+// AddServerMiddlewareRule 添加中间件匹配规则，可以设置多个选择器、排除项与优先级
+func AddServerMiddlewareRule(rule MiddlewareRule) ServerOption {
+	return func(o *ServerOptions) {
+		o.middleware.AddRule(rule)
+	}
+}
+
 func WithServerJSONCodec(JSONCodec codec.Codec) ServerOption {
 	return func(o *ServerOptions) {
 		o.JSONCodec = JSONCodec
@@ -346,6 +384,27 @@ func WithServerEncodeResponseFunc(EncodeResponseFunc EncodeResponseFunc) ServerO
 	}
 }
 
+// WithServerWebSocket 设置websocket配置
+func WithServerWebSocket(opts *WebSocketOptions) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocket = opts
+	}
+}
+
+// WithServerSSEHeartbeat 设置事件流心跳间隔
+func WithServerSSEHeartbeat(interval time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.SSEHeartbeat = interval
+	}
+}
+
+// WithServerCompress 设置压缩配置
+func WithServerCompress(opts *CompressOptions) ServerOption {
+	return func(o *ServerOptions) {
+		o.Compress = opts
+	}
+}
+
 func WithServerLogger(logger *logger.Logger) ServerOption {
 	return func(o *ServerOptions) {
 		o.logger = logger
@@ -373,6 +432,9 @@ func DefaultClientOptions() *ClientOptions {
 		Debug:          false,
 		Timeout:        time.Second * 2,
 		Block:          true,
+		Retry:          retry.DefaultOptions(),
+		Subset:         transport.DefaultSubsetOptions(),
+		retryIf:        isIdempotent,
 		encodeRequest:  defaultRequestEncoder,
 		decodeResponse: defaultResponseDecoder,
 		errorDecoder:   defaultErrorDeCoder,
@@ -434,6 +496,23 @@ func WithClientTlsConfig(conf *tls.Config) ClientOption {
 	}
 }
 
+// WithClientRetry 设置重试策略
+func WithClientRetry(opts ...retry.Option) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Retry == nil {
+			o.Retry = retry.DefaultOptions()
+		}
+		o.Retry = o.Retry.WithOption(opts...)
+	}
+}
+
+// WithClientRetryIf 设置请求是否允许重试的判断方法
+func WithClientRetryIf(fn RetryIfFunc) ClientOption {
+	return func(o *ClientOptions) {
+		o.retryIf = fn
+	}
+}
+
 // WitClientMiddleware 设置中间件
 func WitClientMiddleware(middlewares []transport.Middleware) ClientOption {
 	return func(o *ClientOptions) {
@@ -441,6 +520,40 @@ func WitClientMiddleware(middlewares []transport.Middleware) ClientOption {
 	}
 }
 
+// WithClientBalancer 设置负载均衡器名称，如 round_robin、ring_hash
+func WithClientBalancer(balancer string) ClientOption {
+	return func(o *ClientOptions) {
+		o.Balancer = balancer
+	}
+}
+
+// WithClientOutlier 开启异常节点检测，opts 可通过 transport.DefaultOutlierOptions 创建
+func WithClientOutlier(opts *transport.OutlierOptions) ClientOption {
+	return func(o *ClientOptions) {
+		o.Outlier = opts
+	}
+}
+
+// WithClientSubsetSize 设置实例子集大小，小于等于0时不开启
+func WithClientSubsetSize(size int) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Subset == nil {
+			o.Subset = transport.DefaultSubsetOptions()
+		}
+		o.Subset.Size = size
+	}
+}
+
+// WithClientSubsetKey 设置子集划分的客户端标识，默认使用应用ID
+func WithClientSubsetKey(key string) ClientOption {
+	return func(o *ClientOptions) {
+		if o.Subset == nil {
+			o.Subset = transport.DefaultSubsetOptions()
+		}
+		o.Subset.Key = key
+	}
+}
+
 // WithClientNodeFilters 设置节点过滤器
 func WithClientNodeFilters(filters []transport.NodeFilter) ClientOption {
 	return func(o *ClientOptions) {
@@ -59,17 +59,18 @@ func parseTarget(endpoint string, insecure bool) (*Target, error) {
 
 // resolver 服务发现客户端
 type resolver struct {
-	discovery transport.Discover //
-	selector  transport.Selector // 节点平衡器
-	target    *Target            // 目标地址
-	watcher   transport.Watcher  // 服务监听者
-	insecure  bool               // 是否是不安全的
-	logger    *logger.Logger     // 日志
+	discovery transport.Discover       //
+	selector  transport.Selector       // 节点平衡器
+	target    *Target                  // 目标地址
+	watcher   transport.Watcher        // 服务监听者
+	insecure  bool                     // 是否是不安全的
+	logger    *logger.Logger           // 日志
+	subset    *transport.SubsetOptions // 实例子集划分
 	cycle     *cycle.Cycle
 }
 
 // newResolver 节点解析器
-func newResolver(ctx context.Context, log *logger.Logger, discovery transport.Discover, target *Target, selector transport.Selector, block, insecure bool) (*resolver, error) {
+func newResolver(ctx context.Context, log *logger.Logger, discovery transport.Discover, target *Target, selector transport.Selector, subset *transport.SubsetOptions, block, insecure bool) (*resolver, error) {
 	watcher, err := discovery.Watch(ctx, target.Endpoint)
 	if err != nil {
 		return nil, err
@@ -80,6 +81,7 @@ func newResolver(ctx context.Context, log *logger.Logger, discovery transport.Di
 		watcher:   watcher,
 		selector:  selector,
 		insecure:  insecure,
+		subset:    subset,
 		discovery: discovery,
 		cycle:     cycle.NewCycle(),
 	}
@@ -96,6 +98,8 @@ func newResolver(ctx context.Context, log *logger.Logger, discovery transport.Di
 			}
 		})
 		select {
+		case <-r.cycle.Done():
+			// 已获取到可用节点
 		case err := <-r.cycle.Wait():
 			if err != nil {
 				stopErr := watcher.Stop()
@@ -138,7 +142,9 @@ func (r *resolver) run() error {
 
 // update 修改数据
 func (r *resolver) update(services []*transport.ServiceInfo) bool {
-	nodes := make([]transport.Node, 0)
+	// 先过滤出有可用地址的实例，再划分子集，避免子集中的实例没有对应协议的地址
+	endpoints := make(map[*transport.ServiceInfo]string, len(services))
+	usable := make([]*transport.ServiceInfo, 0, len(services))
 	for _, ins := range services {
 		ept, err := endpoint.ParseEndpoint(ins.Endpoints, endpoint.Scheme("http", !r.insecure))
 		if err != nil {
@@ -148,7 +154,12 @@ func (r *resolver) update(services []*transport.ServiceInfo) bool {
 		if ept == "" {
 			continue
 		}
-		nodes = append(nodes, transport.NewNode("http", ept, ins))
+		endpoints[ins] = ept
+		usable = append(usable, ins)
+	}
+	nodes := make([]transport.Node, 0)
+	for _, ins := range r.subset.Subset(usable) {
+		nodes = append(nodes, transport.NewNode("http", endpoints[ins], ins))
 	}
 	if len(nodes) == 0 {
 		r.logger.Warnf("[http resolver]Zero endpoint found,refused to write,set: %s ins: %v", r.target.Endpoint, nodes)
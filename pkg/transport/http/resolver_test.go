@@ -0,0 +1,124 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"testing"
+	"time"
+)
+
+type fakeDiscovery struct {
+	services chan []*transport.ServiceInfo
+	stop     chan struct{}
+}
+
+func (d *fakeDiscovery) GetService(_ context.Context, _ string) ([]*transport.ServiceInfo, error) {
+	return nil, nil
+}
+
+func (d *fakeDiscovery) Watch(_ context.Context, _ string) (transport.Watcher, error) {
+	return d, nil
+}
+
+func (d *fakeDiscovery) Next() ([]*transport.ServiceInfo, error) {
+	select {
+	case services := <-d.services:
+		return services, nil
+	case <-d.stop:
+		return nil, context.Canceled
+	}
+}
+
+func (d *fakeDiscovery) Stop() error {
+	close(d.stop)
+	return nil
+}
+
+// TestResolverBlock 阻塞模式获取到可用节点后应立即返回，而不是等到上下文超时
+func TestResolverBlock(t *testing.T) {
+	discovery := &fakeDiscovery{
+		services: make(chan []*transport.ServiceInfo, 1),
+		stop:     make(chan struct{}),
+	}
+	discovery.services <- []*transport.ServiceInfo{{
+		ID:        "1",
+		Name:      "greeter",
+		Endpoints: []string{"http://127.0.0.1:8000"},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	selector := transport.GetSelectorBuilder().Build()
+	start := time.Now()
+	r, err := newResolver(ctx, logger.With(), discovery, &Target{Scheme: "discovery", Endpoint: "greeter"}, selector, nil, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("block resolve should return once nodes are found, took %v", elapsed)
+	}
+	node, done, err := selector.Select(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done(context.Background(), transport.DoneInfo{})
+	if node.Address() != "127.0.0.1:8000" {
+		t.Fatalf("want node 127.0.0.1:8000, got %s", node.Address())
+	}
+}
+
+// TestResolverSubsetMixedScheme 子集只在有 http 地址的实例中划分
+func TestResolverSubsetMixedScheme(t *testing.T) {
+	services := make([]*transport.ServiceInfo, 0)
+	for i := 0; i < 10; i++ {
+		services = append(services, &transport.ServiceInfo{
+			ID:        fmt.Sprintf("grpc-%d", i),
+			Name:      "greeter",
+			Endpoints: []string{fmt.Sprintf("grpc://127.0.0.1:%d", 9000+i)},
+		})
+	}
+	services = append(services,
+		&transport.ServiceInfo{ID: "http-1", Name: "greeter", Endpoints: []string{"http://127.0.0.1:8001"}},
+		&transport.ServiceInfo{ID: "http-2", Name: "greeter", Endpoints: []string{"http://127.0.0.1:8002"}},
+	)
+	selector := transport.GetSelectorBuilder().Build()
+	r := &resolver{
+		logger:   logger.With(),
+		target:   &Target{Scheme: "discovery", Endpoint: "greeter"},
+		selector: selector,
+		insecure: true,
+		subset:   &transport.SubsetOptions{Size: 2, Key: "client"},
+	}
+	if !r.update(services) {
+		t.Fatal("expected http nodes in subset")
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node, done, err := selector.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(context.Background(), transport.DoneInfo{})
+		seen[node.Address()] = true
+	}
+	if len(seen) != 2 || !seen["127.0.0.1:8001"] || !seen["127.0.0.1:8002"] {
+		t.Fatalf("want both http nodes, got %v", seen)
+	}
+}
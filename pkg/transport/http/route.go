@@ -0,0 +1,79 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Method     string   `json:"method"`     // 请求方法
+	Path       string   `json:"path"`       // 路由模板
+	Handler    string   `json:"handler"`    // 处理函数名称
+	Handlers   []string `json:"handlers"`   // 处理链中全部函数名称，最后一个为处理函数
+	Middleware int      `json:"middleware"` // 处理链中的中间件数量，不包含按选择器匹配的传输层中间件
+}
+
+func newRouteInfo(method, path string, handlers HandlersChain) RouteInfo {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		names = append(names, nameOfFunction(h))
+	}
+	return RouteInfo{
+		Method:     method,
+		Path:       path,
+		Handler:    names[len(names)-1],
+		Handlers:   names,
+		Middleware: len(handlers) - 1,
+	}
+}
+
+// Routes 按注册顺序返回全部路由
+func (s *Server) Routes() []RouteInfo {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+	routes := make([]RouteInfo, len(s.routes))
+	copy(routes, s.routes)
+	return routes
+}
+
+// MiddlewareRules 按执行顺序返回传输层中间件规则，全局中间件作为第一条无选择器的规则返回
+func (s *Server) MiddlewareRules() []MiddlewareRule {
+	return s.opts.middleware.Rules()
+}
+
+// routeTable 格式化路由表
+func routeTable(routes []RouteInfo) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARE")
+	for _, route := range routes {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", route.Method, route.Path, route.Handler, route.Middleware)
+	}
+	_ = w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// nameOfFunction 获取函数名称
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
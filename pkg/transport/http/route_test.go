@@ -0,0 +1,79 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"strings"
+	"testing"
+)
+
+func getUser(ctx *Context) error {
+	return ctx.SendString("ok")
+}
+
+func TestRoutes(t *testing.T) {
+	auth := func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			return handler(ctx, req)
+		}
+	}
+	srv := NewServer(AddServerMiddleware("!/health", auth))
+	srv.Use(CORS())
+	srv.GET("/users/:id", getUser)
+	srv.Group("/admin", func(ctx *Context) error { return ctx.Next() }).POST("/users", getUser)
+
+	routes := srv.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("want 2 routes, got %d", len(routes))
+	}
+	if r := routes[0]; r.Method != MethodGet || r.Path != "/users/:id" || !strings.HasSuffix(r.Handler, ".getUser") || r.Middleware != 1 {
+		t.Fatalf("unexpected route: %+v", r)
+	}
+	if r := routes[1]; r.Path != "/admin/users" || r.Middleware != 2 || len(r.Handlers) != 3 {
+		t.Fatalf("unexpected route: %+v", r)
+	}
+	if table := routeTable(srv.Routes()); !strings.Contains(table, "POST    /admin/users") {
+		t.Fatalf("unexpected route table:\n%s", table)
+	}
+
+	gs := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(gs, health.NewServer())
+	srv.Debug("/debug/routes", gs)
+	resp := serveRequest(srv, MethodGet, "/debug/routes", nil)
+	var info debugInfo
+	if err := json.Unmarshal(resp.Body(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.HTTP.Routes) != 3 || len(info.HTTP.Middleware) != 1 || info.HTTP.Middleware[0].Excludes[0] != "/health" {
+		t.Fatalf("unexpected http debug info: %+v", info.HTTP)
+	}
+	if len(info.GRPC) != 1 || info.GRPC[0].Services[0].Name != "grpc.health.v1.Health" {
+		t.Fatalf("unexpected grpc debug info: %+v", info.GRPC)
+	}
+	var watch bool
+	for _, m := range info.GRPC[0].Services[0].Methods {
+		watch = watch || (m.FullMethod == "/grpc.health.v1.Health/Watch" && m.ServerStreaming)
+	}
+	if !watch {
+		t.Fatalf("want streaming Watch method, got %+v", info.GRPC[0].Services[0].Methods)
+	}
+}
@@ -61,6 +61,9 @@ type IRoutes interface {
 	OPTIONS(string, ...HandlerFunc) IRoutes
 	TRACE(string, ...HandlerFunc) IRoutes
 	HEAD(string, ...HandlerFunc) IRoutes
+	WS(string, WebSocketHandler, ...HandlerFunc) IRoutes
+	Swagger([]byte, ...SwaggerOption) IRoutes
+	Debug(string, ...GRPCServiceInfo) IRoutes
 	StaticFile(string, string) IRoutes
 	Static(string, string) IRoutes
 	StaticFS(string, *fasthttp.FS) IRoutes
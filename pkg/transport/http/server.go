@@ -31,6 +31,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -41,6 +42,7 @@ const (
 var (
 	_ transport.Transport  = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Drainer    = (*Server)(nil)
 )
 
 // Server 服务定义
@@ -55,7 +57,12 @@ type Server struct {
 	maxParams   uint16
 	opts        *ServerOptions
 	trees       Routers
-	RouterGroup // 路由
+	routesMu    sync.RWMutex       // 保护 routes
+	routes      []RouteInfo        // 按注册顺序记录的路由
+	inflight    atomic.Int64       // 处理中的请求数
+	stopCtx     context.Context    // 服务停止时取消，用于关闭websocket连接与事件流
+	stopCancel  context.CancelFunc // 取消 stopCtx
+	RouterGroup                    // 路由
 }
 
 // NewServer 新建
@@ -83,6 +90,7 @@ func NewWithOptions(options *ServerOptions) *Server {
 		srv.trees = append(srv.trees, &Router{method: method, root: &node{}})
 	}
 	srv.RouterGroup.server = srv
+	srv.stopCtx, srv.stopCancel = context.WithCancel(context.Background())
 	// 初始化服务
 	srv.init()
 	return srv
@@ -101,6 +109,11 @@ func (s *Server) Endpoint() (*url.URL, error) {
 	return s.endpoint, nil
 }
 
+// Inflight 处理中的请求数
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
 // Start 启动
 func (s *Server) Start(ctx context.Context) error {
 	if err := s.listenAndEndpoint(); err != nil {
@@ -108,6 +121,9 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 	s.baseContext = ctx
 	s.opts.logger.Infof("[HTTP] server listening on: %s", s.listener.Addr().String())
+	if routes := s.Routes(); !s.opts.DisablePrintRoute && len(routes) > 0 {
+		s.opts.logger.Infof("[HTTP] registered routes:\n%s", routeTable(routes))
+	}
 	var err error
 	listener := s.listener
 	if s.opts.TlsConf != nil {
@@ -207,15 +223,27 @@ func (s *Server) next(ctx *Context) (err error) {
 	if s.endpoint != nil {
 		metadata.endpoint = s.endpoint.String()
 	}
-	ctx.SetUserContext(transport.NewMetadataServerContext(s.baseContext, metadata))
+	userCtx := transport.NewMetadataServerContext(s.baseContext, metadata)
+	if timeout := s.timeout(ctx, value.pathTemplate); timeout > 0 {
+		var cancel context.CancelFunc
+		userCtx, cancel = context.WithTimeout(userCtx, timeout)
+		defer cancel()
+		ctx.timeoutCtx = userCtx
+	}
+	ctx.SetUserContext(userCtx)
 	ctx.handlers = value.handlers
 	ctx.pathTemplate = value.pathTemplate
 	err = ctx.Next()
+	if ctx.timeoutCtx != nil {
+		err = deadlineExceeded(ctx.timeoutCtx, err)
+	}
 	return
 }
 
 // handler 入口
 func (s *Server) handler(fastCtx *fasthttp.RequestCtx) {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
 	// 分配上下文
 	ctx := s.acquireContext(fastCtx)
 	// 释放ctx
@@ -225,12 +253,16 @@ func (s *Server) handler(fastCtx *fasthttp.RequestCtx) {
 		_ = ctx.SetStatusCode(StatusBadRequest).SendString(default405Body)
 		return
 	}
-	err := s.next(ctx)
+	err := s.decompress(ctx)
+	if err == nil {
+		err = s.next(ctx)
+	}
 	if err != nil {
 		if catch := s.opts.ErrorHandler(ctx, err); catch != nil {
 			_ = ctx.SendStatus(StatusInternalServerError)
 		}
 	}
+	s.compress(ctx)
 }
 
 // addRoute 添加路由
@@ -242,9 +274,9 @@ func (s *Server) addRoute(method, path string, handlers HandlersChain) {
 	assert.Panic(method != "", "HTTP method can not be empty")
 	assert.Panic(len(handlers) > 0, "there must be at least one handler")
 
-	if !s.opts.DisablePrintRoute {
-		//debugPrintRoute(method, path, handlers)
-	}
+	s.routesMu.Lock()
+	s.routes = append(s.routes, newRouteInfo(method, path, handlers))
+	s.routesMu.Unlock()
 
 	methodRouter := s.trees.get(method)
 	if methodRouter == nil {
@@ -262,6 +294,7 @@ func (s *Server) addRoute(method, path string, handlers HandlersChain) {
 // Stop 关闭
 func (s *Server) Stop(ctx context.Context) error {
 	s.opts.logger.Info("[HTTP] server stopping")
+	s.stopCancel()
 	return s.server.ShutdownWithContext(ctx)
 }
 
@@ -0,0 +1,223 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ grpc.ServerStream = (*SSEServerStream)(nil)
+
+// MIMETextEventStream 事件流类型
+const MIMETextEventStream = "text/event-stream"
+
+// SSEvent 服务端推送事件
+type SSEvent struct {
+	ID    string        // 事件id，客户端重连时通过 Last-Event-ID 请求头带回
+	Event string        // 事件类型，为空时客户端按 message 处理
+	Retry time.Duration // 客户端重连间隔
+	Data  []byte        // 事件数据，多行数据按行拆分
+}
+
+// SSEHandler 事件流处理方法，ctx 在客户端断开或服务停止时取消，返回错误时以 error 事件发送给客户端
+type SSEHandler func(ctx context.Context, w *SSEWriter) error
+
+// SSEWriter 事件流写入器，可以并发调用，每个事件写入后立即发送
+type SSEWriter struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	cancel context.CancelFunc
+	err    error
+}
+
+// Send 发送事件
+func (w *SSEWriter) Send(event *SSEvent) error {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + sseEscape(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + sseEscape(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range bytes.Split(event.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return w.write(buf.Bytes())
+}
+
+// SendData 发送只有数据的事件
+func (w *SSEWriter) SendData(data []byte) error {
+	return w.Send(&SSEvent{Data: data})
+}
+
+// Comment 发送注释，客户端会忽略注释，可以用于保持连接
+func (w *SSEWriter) Comment(text string) error {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(": " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	return w.write(buf.Bytes())
+}
+
+// write 写入并发送数据，写入失败说明客户端已断开，取消事件流上下文
+func (w *SSEWriter) write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := w.w.Write(p); err != nil {
+		w.fail(err)
+		return err
+	}
+	if err := w.w.Flush(); err != nil {
+		w.fail(err)
+		return err
+	}
+	return nil
+}
+
+func (w *SSEWriter) fail(err error) {
+	w.err = err
+	w.cancel()
+}
+
+// sseEscape 字段值中不能包含换行
+func sseEscape(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// SSE 以事件流响应请求，请求会先执行通用中间件，handler 在本方法返回后执行，不能再使用当前 Context
+func (ctx *Context) SSE(handler SSEHandler) error {
+	return ctx.sse(nil, handler)
+}
+
+// SSEStream 以事件流响应服务端流式方法，in 为绑定的请求，供 protoc-gen-ceres 生成的代码使用
+func (ctx *Context) SSEStream(in interface{}, handler func(stream *SSEServerStream) error) error {
+	encoder := ctx.server.opts.JSONCodec
+	return ctx.sse(in, func(c context.Context, w *SSEWriter) error {
+		return handler(&SSEServerStream{ctx: c, w: w, marshal: encoder.Marshal})
+	})
+}
+
+// sse 以 req 为请求执行通用中间件后开始事件流
+func (ctx *Context) sse(req interface{}, handler SSEHandler) error {
+	s := ctx.server
+	streamCtx, metadata, cancel, err := s.streamContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	// 中间件设置的响应头随事件流响应返回，之后不再引用请求的响应
+	metadata.response = &Response{}
+	streamCtx, cancelStream := context.WithCancel(streamCtx)
+	ctx.SetResponseHeader(HeaderContentType, MIMETextEventStream)
+	ctx.SetResponseHeader("Cache-Control", "no-cache")
+	ctx.SetResponseHeader("Connection", "keep-alive")
+	ctx.SetResponseHeader("X-Accel-Buffering", "no")
+	ctx.SetStatusCode(StatusOK)
+	heartbeat := s.opts.SSEHeartbeat
+	ctx.fastCtx.SetBodyStreamWriter(func(bw *bufio.Writer) {
+		defer cancel()
+		defer cancelStream()
+		w := &SSEWriter{w: bw, cancel: cancelStream}
+		// 先发送一条空注释，使响应头尽早到达客户端
+		if err := w.write([]byte(":\n\n")); err != nil {
+			return
+		}
+		var wg sync.WaitGroup
+		if heartbeat > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(heartbeat)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-streamCtx.Done():
+						return
+					case <-ticker.C:
+						if w.Comment("heartbeat") != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+		if err := handler(streamCtx, w); err != nil && streamCtx.Err() == nil {
+			_ = w.Send(&SSEvent{Event: "error", Data: []byte(errors.FromError(err).Json())})
+		}
+		// 写入器在本方法返回后失效，等待心跳协程退出
+		cancelStream()
+		wg.Wait()
+	})
+	return nil
+}
+
+// SSEServerStream 基于事件流的服务端流，实现 grpc.ServerStream，每条消息以json编码为一个事件，
+// 使 grpc 服务端流式方法的实现可以直接用于http
+type SSEServerStream struct {
+	ctx     context.Context
+	w       *SSEWriter
+	marshal func(v interface{}) ([]byte, error)
+}
+
+// SetHeader 事件流开始后响应头已经发送，不支持设置
+func (s *SSEServerStream) SetHeader(grpcMetadata.MD) error {
+	return errors.InternalServer("SSE_HEADER_SENT", "response header has been sent")
+}
+
+// SendHeader 事件流开始后响应头已经发送，不支持设置
+func (s *SSEServerStream) SendHeader(grpcMetadata.MD) error {
+	return errors.InternalServer("SSE_HEADER_SENT", "response header has been sent")
+}
+
+// SetTrailer 事件流不支持trailer，忽略
+func (s *SSEServerStream) SetTrailer(grpcMetadata.MD) {}
+
+// Context 事件流上下文
+func (s *SSEServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg 发送一条消息
+func (s *SSEServerStream) SendMsg(m interface{}) error {
+	data, err := s.marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.w.SendData(data)
+}
+
+// RecvMsg 服务端流的请求已在建立事件流前绑定，不支持接收
+func (s *SSEServerStream) RecvMsg(interface{}) error {
+	return errors.InternalServer("SSE_RECV_UNSUPPORTED", "server-sent events stream can not receive messages")
+}
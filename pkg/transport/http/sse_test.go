@@ -0,0 +1,116 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSSEServer(t *testing.T, opts ...ServerOption) (*Server, *fasthttputil.InmemoryListener) {
+	ln := fasthttputil.NewInmemoryListener()
+	srv := NewServer(opts...)
+	srv.listener = ln
+	srv.endpoint = &url.URL{Scheme: "http", Host: "127.0.0.1:5200"}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+	return srv, ln
+}
+
+// openStream 发送请求并返回响应读取器
+func openStream(t *testing.T, ln *fasthttputil.InmemoryListener, path string) (net.Conn, *bufio.Reader) {
+	conn, err := ln.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Write([]byte("GET " + path + " HTTP/1.1\r\nHost: 127.0.0.1\r\nAccept: text/event-stream\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	return conn, bufio.NewReader(conn)
+}
+
+// readUntil 读取响应直到包含指定内容
+func readUntil(t *testing.T, r *bufio.Reader, want string) string {
+	var sb strings.Builder
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(sb.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want %q, got %q", want, sb.String())
+		}
+		line, err := r.ReadString('\n')
+		sb.WriteString(line)
+		if err != nil {
+			t.Fatalf("want %q, got %q: %v", want, sb.String(), err)
+		}
+	}
+	return sb.String()
+}
+
+func TestSSE(t *testing.T) {
+	disconnected := make(chan struct{})
+	srv, ln := newSSEServer(t, WithServerSSEHeartbeat(20*time.Millisecond))
+	srv.GET("/events", func(ctx *Context) error {
+		return ctx.SSE(func(c context.Context, w *SSEWriter) error {
+			if err := w.Send(&SSEvent{ID: "1", Event: "progress", Retry: time.Second, Data: []byte("50\n%")}); err != nil {
+				return err
+			}
+			<-c.Done()
+			close(disconnected)
+			return nil
+		})
+	})
+	srv.GET("/fail", func(ctx *Context) error {
+		return ctx.SSEStream(nil, func(stream *SSEServerStream) error {
+			if err := stream.SendMsg(map[string]int{"progress": 100}); err != nil {
+				return err
+			}
+			return errors.NotFound("NOT_FOUND", "task not found")
+		})
+	})
+
+	conn, r := openStream(t, ln, "/events")
+	head := readUntil(t, r, "\r\n\r\n")
+	if !strings.Contains(head, "Content-Type: text/event-stream") {
+		t.Fatalf("unexpected header %q", head)
+	}
+	readUntil(t, r, "id: 1\nevent: progress\nretry: 1000\ndata: 50\ndata: %\n\n")
+	readUntil(t, r, ": heartbeat\n")
+	// 客户端断开后事件流上下文取消
+	_ = conn.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("stream context must be canceled after client disconnect")
+	}
+
+	conn, r = openStream(t, ln, "/fail")
+	defer conn.Close()
+	readUntil(t, r, `data: {"progress":100}`)
+	if event := readUntil(t, r, "event: error\ndata: {"); !strings.Contains(event, "NOT_FOUND") {
+		t.Fatalf("want error event, got %q", event)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// swaggerTemplate Swagger UI 页面
+var swaggerTemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.AssetsURL}}/swagger-ui-bundle.js" crossorigin></script>
+<script>
+  window.onload = function () {
+    window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui", deepLinking: true});
+  };
+</script>
+</body>
+</html>
+`))
+
+// SwaggerOption 接口文档配置方法
+type SwaggerOption func(o *SwaggerOptions)
+
+// SwaggerOptions 接口文档配置
+type SwaggerOptions struct {
+	SpecPath  string `json:"specPath"`  // 文档路径，默认值：/openapi.json
+	UIPath    string `json:"uiPath"`    // 页面路径，默认值：/swagger
+	Title     string `json:"title"`     // 页面标题，默认值：Swagger UI
+	AssetsURL string `json:"assetsURL"` // swagger-ui-dist 静态资源地址，内网环境可替换为自建地址
+}
+
+// DefaultSwaggerOptions 默认接口文档配置
+func DefaultSwaggerOptions() *SwaggerOptions {
+	return &SwaggerOptions{
+		SpecPath:  "/openapi.json",
+		UIPath:    "/swagger",
+		Title:     "Swagger UI",
+		AssetsURL: "https://unpkg.com/swagger-ui-dist@5",
+	}
+}
+
+// WithSwaggerSpecPath 设置文档路径
+func WithSwaggerSpecPath(path string) SwaggerOption {
+	return func(o *SwaggerOptions) {
+		o.SpecPath = path
+	}
+}
+
+// WithSwaggerUIPath 设置页面路径
+func WithSwaggerUIPath(path string) SwaggerOption {
+	return func(o *SwaggerOptions) {
+		o.UIPath = path
+	}
+}
+
+// WithSwaggerTitle 设置页面标题
+func WithSwaggerTitle(title string) SwaggerOption {
+	return func(o *SwaggerOptions) {
+		o.Title = title
+	}
+}
+
+// WithSwaggerAssetsURL 设置静态资源地址
+func WithSwaggerAssetsURL(url string) SwaggerOption {
+	return func(o *SwaggerOptions) {
+		o.AssetsURL = url
+	}
+}
+
+// Swagger 注册接口文档与 Swagger UI 页面，spec 为 protoc-gen-ceres-openapi 生成的文档，通常通过 go:embed 引入
+func (group *RouterGroup) Swagger(spec []byte, opts ...SwaggerOption) IRoutes {
+	o := DefaultSwaggerOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	contentType := "application/yaml"
+	if trimmed := bytes.TrimSpace(spec); len(trimmed) > 0 && trimmed[0] == '{' {
+		contentType = MIMEApplicationJSONCharsetUTF8
+	}
+	var page bytes.Buffer
+	if err := swaggerTemplate.Execute(&page, map[string]string{
+		"Title":     o.Title,
+		"AssetsURL": o.AssetsURL,
+		"SpecURL":   group.calculateAbsolutePath(o.SpecPath),
+	}); err != nil {
+		panic(err)
+	}
+	group.GET(o.SpecPath, func(ctx *Context) error {
+		ctx.SetResponseHeader(HeaderContentType, contentType)
+		_, err := ctx.Write(spec)
+		return err
+	})
+	return group.GET(o.UIPath, func(ctx *Context) error {
+		ctx.SetResponseHeader(HeaderContentType, MIMETextHTMLCharsetUTF8)
+		_, err := ctx.Write(page.Bytes())
+		return err
+	})
+}
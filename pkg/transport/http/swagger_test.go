@@ -0,0 +1,40 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSwagger(t *testing.T) {
+	spec := []byte(`{"openapi":"3.0.3"}`)
+	srv := NewServer()
+	srv.Group("/docs").Swagger(spec, WithSwaggerTitle("User API"))
+
+	resp := serveRequest(srv, MethodGet, "/docs/openapi.json", nil)
+	if resp.StatusCode() != StatusOK || string(resp.Body()) != string(spec) {
+		t.Fatalf("unexpected spec response: %d %s", resp.StatusCode(), resp.Body())
+	}
+	if got := string(resp.Header.ContentType()); got != MIMEApplicationJSONCharsetUTF8 {
+		t.Fatalf("want json content type, got %s", got)
+	}
+	resp = serveRequest(srv, MethodGet, "/docs/swagger", nil)
+	page := string(resp.Body())
+	if !strings.Contains(page, "<title>User API</title>") || !strings.Contains(page, `url: "/docs/openapi.json"`) {
+		t.Fatalf("unexpected swagger page: %s", page)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"github.com/fasthttp/websocket"
+	ic "github.com/go-ceres/ceres/internal/context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/valyala/fasthttp"
+	"net"
+	"sync"
+	"time"
+)
+
+// websocket 消息类型
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+// websocket 关闭码
+const (
+	CloseNormalClosure     = websocket.CloseNormalClosure
+	CloseGoingAway         = websocket.CloseGoingAway
+	CloseInternalServerErr = websocket.CloseInternalServerErr
+)
+
+// WebSocketHandler websocket连接处理方法，返回后连接关闭
+type WebSocketHandler func(conn *WebSocketConn) error
+
+// WebSocketOptions websocket配置
+type WebSocketOptions struct {
+	HandshakeTimeout  time.Duration                       `json:"handshakeTimeout"`  // 握手超时时间
+	ReadBufferSize    int                                 `json:"readBufferSize"`    // 读取缓冲区大小，默认值：4096
+	WriteBufferSize   int                                 `json:"writeBufferSize"`   // 写入缓冲区大小，默认值：4096
+	ReadLimit         int64                               `json:"readLimit"`         // 单条消息的最大字节数，小于等于0时不限制
+	PingInterval      time.Duration                       `json:"pingInterval"`      // 发送ping的间隔，小于等于0时不发送，默认值：30s
+	PongWait          time.Duration                       `json:"pongWait"`          // 等待pong或消息的最长时间，超过后连接断开，默认值：60s
+	WriteTimeout      time.Duration                       `json:"writeTimeout"`      // 写入超时时间，默认值：10s
+	EnableCompression bool                                `json:"enableCompression"` // 是否协商消息压缩
+	Subprotocols      []string                            `json:"subprotocols"`      // 支持的子协议
+	CheckOrigin       func(ctx *fasthttp.RequestCtx) bool `json:"-"`                 // 校验请求来源，为空时只允许同源请求
+}
+
+// DefaultWebSocketOptions 默认websocket配置
+func DefaultWebSocketOptions() *WebSocketOptions {
+	return &WebSocketOptions{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		PingInterval:    30 * time.Second,
+		PongWait:        60 * time.Second,
+		WriteTimeout:    10 * time.Second,
+	}
+}
+
+// WebSocketConn websocket连接，读取只能在一个协程中进行，写入可以并发调用
+type WebSocketConn struct {
+	conn      *websocket.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	opts      *WebSocketOptions
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newWebSocketConn(ctx context.Context, conn *websocket.Conn, opts *WebSocketOptions) *WebSocketConn {
+	c := &WebSocketConn{
+		conn: conn,
+		opts: opts,
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	if opts.ReadLimit > 0 {
+		conn.SetReadLimit(opts.ReadLimit)
+	}
+	if opts.PongWait > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(opts.PongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(opts.PongWait))
+		})
+	}
+	return c
+}
+
+// Context 连接的上下文，包含升级请求的元数据与中间件设置的值，连接关闭或服务停止时取消
+func (c *WebSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+// Subprotocol 协商的子协议
+func (c *WebSocketConn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// RemoteAddr 客户端地址
+func (c *WebSocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage 读取一条消息，收到消息时延长读取截止时间
+func (c *WebSocketConn) ReadMessage() (messageType int, data []byte, err error) {
+	messageType, data, err = c.conn.ReadMessage()
+	if err == nil && c.opts.PongWait > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+	}
+	return
+}
+
+// WriteMessage 写入一条消息
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.opts.WriteTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+	}
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// WriteText 写入文本消息
+func (c *WebSocketConn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteBinary 写入二进制消息
+func (c *WebSocketConn) WriteBinary(data []byte) error {
+	return c.WriteMessage(BinaryMessage, data)
+}
+
+// Close 正常关闭连接
+func (c *WebSocketConn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode 发送关闭帧后关闭连接
+func (c *WebSocketConn) CloseWithCode(code int, text string) error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		deadline := time.Now().Add(time.Second)
+		if c.opts.WriteTimeout > 0 {
+			deadline = time.Now().Add(c.opts.WriteTimeout)
+		}
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+		c.closeErr = c.conn.Close()
+	})
+	return c.closeErr
+}
+
+// keepalive 定时发送ping，上下文取消时关闭连接
+func (c *WebSocketConn) keepalive() {
+	var tick <-chan time.Time
+	if c.opts.PingInterval > 0 {
+		ticker := time.NewTicker(c.opts.PingInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-c.ctx.Done():
+			_ = c.CloseWithCode(CloseGoingAway, "server shutting down")
+			return
+		case <-tick:
+			deadline := time.Now().Add(time.Second)
+			if c.opts.WriteTimeout > 0 {
+				deadline = time.Now().Add(c.opts.WriteTimeout)
+			}
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				_ = c.CloseWithCode(CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// IsUnexpectedCloseError 是否为非正常关闭的错误
+func IsUnexpectedCloseError(err error) bool {
+	return websocket.IsUnexpectedCloseError(err, CloseNormalClosure, CloseGoingAway)
+}
+
+// WS 注册websocket路由，升级请求会执行路由中间件与通用中间件，通用中间件对上下文的修改保留在连接上下文中
+func (group *RouterGroup) WS(relativePath string, handler WebSocketHandler, handlers ...HandlerFunc) IRoutes {
+	srv := group.server
+	return group.GET(relativePath, append(handlers, func(ctx *Context) error {
+		return srv.upgrade(ctx, handler)
+	})...)
+}
+
+// upgrade 执行中间件后升级为websocket连接
+func (s *Server) upgrade(ctx *Context, handler WebSocketHandler) error {
+	opts := s.opts.WebSocket
+	if opts == nil {
+		opts = DefaultWebSocketOptions()
+	}
+	connCtx, metadata, cancel, err := s.streamContext(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// 中间件设置的响应头随握手响应返回，之后不再引用请求的响应
+	metadata.response = &Response{}
+	upgrader := &websocket.FastHTTPUpgrader{
+		HandshakeTimeout:  opts.HandshakeTimeout,
+		ReadBufferSize:    opts.ReadBufferSize,
+		WriteBufferSize:   opts.WriteBufferSize,
+		Subprotocols:      opts.Subprotocols,
+		EnableCompression: opts.EnableCompression,
+		CheckOrigin:       opts.CheckOrigin,
+	}
+	err = upgrader.Upgrade(ctx.fastCtx, func(c *websocket.Conn) {
+		defer cancel()
+		conn := newWebSocketConn(connCtx, c, opts)
+		go conn.keepalive()
+		if err := handler(conn); err != nil {
+			s.opts.logger.Errorf("[HTTP] websocket handler error: %v", err)
+			_ = conn.CloseWithCode(CloseInternalServerErr, "")
+			return
+		}
+		_ = conn.Close()
+	})
+	if err != nil {
+		cancel()
+		return errors.BadRequest("WEBSOCKET_UPGRADE_FAILED", err.Error())
+	}
+	return nil
+}
+
+// streamContext 为生命周期长于请求的连接创建上下文，元数据使用请求头的副本，
+// 以 req 为请求执行通用中间件并保留其对上下文的修改，返回的元数据在中间件执行期间引用请求的响应
+func (s *Server) streamContext(ctx *Context, req interface{}) (context.Context, *Metadata, context.CancelFunc, error) {
+	request := &Request{}
+	ctx.Request().Header.CopyTo(&request.Header)
+	ctx.Request().URI().CopyTo(request.URI())
+	metadata := &Metadata{
+		operation:    ctx.pathTemplate,
+		pathTemplate: ctx.pathTemplate,
+		request:      request,
+		response:     ctx.Response(),
+	}
+	if tr, ok := transport.MetadataFromServerContext(ctx.UserContext()); ok {
+		metadata.operation = tr.Operation()
+	}
+	if s.endpoint != nil {
+		metadata.endpoint = s.endpoint.String()
+	}
+	// 服务停止时取消，websocket连接与事件流随之关闭
+	baseCtx, cancel := ic.Merge(transport.NewMetadataServerContext(s.baseContext, metadata), s.stopCtx)
+	var streamCtx context.Context
+	_, err := ctx.Middleware(func(c context.Context, _ interface{}) (interface{}, error) {
+		streamCtx = c
+		return nil, nil
+	})(baseCtx, req)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	return streamCtx, metadata, cancel, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"github.com/fasthttp/websocket"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type wsKey struct{}
+
+func TestWebSocket(t *testing.T) {
+	ln := fasthttputil.NewInmemoryListener()
+	mw := func(handler transport.Handler) transport.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.MetadataFromServerContext(ctx); ok {
+				tr.ReplyHeader().Set("X-Operation", tr.Operation())
+				ctx = context.WithValue(ctx, wsKey{}, tr.RequestHeader().Get("X-User"))
+			}
+			return handler(ctx, req)
+		}
+	}
+	srv := NewServer(AddServerMiddleware("/ws/:room", mw), WithServerWebSocket(&WebSocketOptions{
+		PingInterval: 20 * time.Millisecond,
+		PongWait:     time.Second,
+		WriteTimeout: time.Second,
+	}))
+	srv.listener = ln
+	srv.endpoint = &url.URL{Scheme: "http", Host: "127.0.0.1:5200"}
+	closed := make(chan error, 1)
+	srv.WS("/ws/:room", func(conn *WebSocketConn) error {
+		user, _ := conn.Context().Value(wsKey{}).(string)
+		if err := conn.WriteText("hello " + user); err != nil {
+			return err
+		}
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				closed <- err
+				return nil
+			}
+			if err = conn.WriteMessage(typ, data); err != nil {
+				return err
+			}
+		}
+	})
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+
+	dialer := websocket.Dialer{NetDial: func(_, _ string) (net.Conn, error) {
+		return ln.Dial()
+	}}
+	header := map[string][]string{"X-User": {"ceres"}}
+	conn, resp, err := dialer.Dial("ws://127.0.0.1:5200/ws/1", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if got := resp.Header.Get("X-Operation"); got != "/ws/:room" {
+		t.Fatalf("middleware must run on upgrade request, got operation %q", got)
+	}
+	pings := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+	})
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "hello ceres" {
+		t.Fatalf("unexpected greeting %q %v", data, err)
+	}
+	if err = conn.WriteMessage(websocket.BinaryMessage, []byte("echo")); err != nil {
+		t.Fatal(err)
+	}
+	if typ, data, err := conn.ReadMessage(); err != nil || typ != websocket.BinaryMessage || string(data) != "echo" {
+		t.Fatalf("unexpected echo %d %q %v", typ, data, err)
+	}
+	// 读取时处理服务端的ping，服务停止时连接收到关闭帧
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		readErr <- err
+	}()
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("want server ping")
+	}
+	go func() {
+		_ = srv.Stop(context.Background())
+	}()
+	select {
+	case err = <-readErr:
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Fatalf("want going away close, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stop must close the connection")
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("handler must return after stop")
+	}
+}
@@ -49,6 +49,20 @@ type DoneInfo struct {
 
 type DoneFunc func(ctx context.Context, di DoneInfo)
 
+// IsFailure 判断一次调用结果是否计为节点失败，节点健康度与熔断器等组件共用该判定
+func IsFailure(err error, errHandler func(err error) (isErr bool)) bool {
+	if err == nil {
+		return false
+	}
+	if errHandler != nil && errHandler(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err) || errors.IsTooManyRequests(err) ||
+		errors.As(err, &netErr)
+}
+
 // WeightNodeBuilder 权重节点构建器接口
 type WeightNodeBuilder interface {
 	Build(node Node) IWeightedNode
@@ -69,6 +83,7 @@ func (w *DefaultWeightNodeBuilder) Build(node Node) IWeightedNode {
 		inflights:  list.New(),
 		errHandler: w.ErrHandler,
 	}
+	s.node.Store(nodeHolder{node})
 	return s
 }
 
@@ -81,10 +96,35 @@ type IWeightedNode interface {
 	PickElapsed() time.Duration //最近一次获取节点到现在的时间差
 }
 
+// WeightedNodeUpdater 可替换原始节点的权重节点，节点信息更新时保留统计数据
+type WeightedNodeUpdater interface {
+	Update(node Node)
+}
+
+// NodeStats 节点运行时统计信息
+type NodeStats interface {
+	Lag() time.Duration // 平均响应延迟
+	Success() float64   // 请求成功率，取值0~1
+	Inflight() int64    // 正在处理的请求数
+}
+
+var (
+	_ NodeStats           = (*DefaultWeightedNode)(nil)
+	_ WeightedNodeUpdater = (*DefaultWeightedNode)(nil)
+)
+
+// nodeHolder 保证 atomic.Value 中存储的类型一致
+type nodeHolder struct {
+	Node
+}
+
 // DefaultWeightedNode 默认权重节点
 type DefaultWeightedNode struct {
+	// Node 创建时的原始节点，为兼容旧版本保留，节点可能被 Update 替换，请使用 Raw 获取当前节点
 	Node
 
+	node atomic.Value // 当前原始节点
+
 	// client statistic data
 	lag       int64
 	success   uint64
@@ -192,15 +232,8 @@ func (wn *DefaultWeightedNode) Pick() DoneFunc {
 		atomic.StoreInt64(&wn.lag, lag)
 
 		success := uint64(1000) // error value ,if error set 1
-		if di.Err != nil {
-			if wn.errHandler != nil && wn.errHandler(di.Err) {
-				success = 0
-			}
-			var netErr net.Error
-			if errors.Is(context.DeadlineExceeded, di.Err) || errors.Is(context.Canceled, di.Err) ||
-				errors.IsServiceUnavailable(di.Err) || errors.IsGatewayTimeout(di.Err) || errors.As(di.Err, &netErr) {
-				success = 0
-			}
+		if IsFailure(di.Err, wn.errHandler) {
+			success = 0
 		}
 		oldSuc := atomic.LoadUint64(&wn.success)
 		success = uint64(float64(oldSuc)*w + float64(success)*(1.0-w))
@@ -214,12 +247,53 @@ func (wn *DefaultWeightedNode) Weight() (weight float64) {
 	return
 }
 
+// Lag 平均响应延迟
+func (wn *DefaultWeightedNode) Lag() time.Duration {
+	return time.Duration(atomic.LoadInt64(&wn.lag))
+}
+
+// Success 请求成功率
+func (wn *DefaultWeightedNode) Success() float64 {
+	return float64(wn.health()) / 1000
+}
+
+// Inflight 正在处理的请求数
+func (wn *DefaultWeightedNode) Inflight() int64 {
+	// inflight 初始值为1，用于负载计算
+	return atomic.LoadInt64(&wn.inflight) - 1
+}
+
 func (wn *DefaultWeightedNode) PickElapsed() time.Duration {
 	return time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&wn.lastPick))
 }
 
 func (wn *DefaultWeightedNode) Raw() Node {
-	return wn.Node
+	return wn.node.Load().(nodeHolder).Node
+}
+
+// Update 替换原始节点，保留统计数据
+func (wn *DefaultWeightedNode) Update(node Node) {
+	wn.node.Store(nodeHolder{node})
+}
+
+// Scheme 节点协议
+func (wn *DefaultWeightedNode) Scheme() string {
+	return wn.Raw().Scheme()
+}
+
+// Address 节点地址
+func (wn *DefaultWeightedNode) Address() string {
+	return wn.Raw().Address()
+}
+
+// InitialWeight 初始化权重
+func (wn *DefaultWeightedNode) InitialWeight() *int64 {
+	return wn.Raw().InitialWeight()
+}
+
+// ServiceInfo 服务信息
+func (wn *DefaultWeightedNode) ServiceInfo() *ServiceInfo {
+	return wn.Raw().ServiceInfo()
 }
 
 // Node 服务节点接口
@@ -0,0 +1,294 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/logger"
+	"math"
+	"sync"
+	"time"
+)
+
+// OutlierEventType 异常节点事件类型
+type OutlierEventType string
+
+const (
+	// OutlierConsecutiveFailures 连续失败导致驱逐
+	OutlierConsecutiveFailures OutlierEventType = "consecutive_failures"
+	// OutlierSuccessRate 成功率明显低于其他节点导致驱逐
+	OutlierSuccessRate OutlierEventType = "success_rate"
+	// OutlierUneject 驱逐时间结束，节点恢复
+	OutlierUneject OutlierEventType = "uneject"
+)
+
+// OutlierEvent 异常节点事件
+type OutlierEvent struct {
+	Type         OutlierEventType // 事件类型
+	Node         Node             // 节点
+	EjectionTime time.Duration    // 本次驱逐时长，恢复事件为0
+	SuccessRate  float64          // 成功率驱逐时节点的成功率
+}
+
+// OutlierOptions 异常节点检测配置，检测结果来自每次调用的 DoneInfo，
+// 驱逐、恢复与成功率统计均在调用结束时进行，选择节点时只读取驱逐状态
+type OutlierOptions struct {
+	Interval                 time.Duration            `json:"interval"`                 // 成功率统计周期，默认10s
+	ConsecutiveFailures      int                      `json:"consecutiveFailures"`      // 连续失败多少次后驱逐，默认5，小于等于0时关闭
+	SuccessRateMinimumHosts  int                      `json:"successRateMinimumHosts"`  // 参与成功率统计的最少节点数，默认5
+	SuccessRateRequestVolume int64                    `json:"successRateRequestVolume"` // 统计周期内参与成功率统计的最少请求数，默认100
+	SuccessRateStdevFactor   float64                  `json:"successRateStdevFactor"`   // 成功率低于 平均值-标准差*系数 时驱逐，默认1.9，小于等于0时关闭
+	BaseEjectionTime         time.Duration            `json:"baseEjectionTime"`         // 基础驱逐时长，每次驱逐翻倍，默认30s
+	MaxEjectionTime          time.Duration            `json:"maxEjectionTime"`          // 最大驱逐时长，默认300s
+	MaxEjectionPercent       int                      `json:"maxEjectionPercent"`       // 最多驱逐节点百分比，至少允许驱逐一个节点，默认10
+	ErrHandler               func(err error) bool     `json:"-"`                        // 自定义失败判定
+	OnEvent                  func(event OutlierEvent) `json:"-"`                        // 驱逐与恢复事件钩子
+	Logger                   *logger.Logger           `json:"-"`                        // 日志
+}
+
+// DefaultOutlierOptions 默认异常节点检测配置
+func DefaultOutlierOptions() *OutlierOptions {
+	return &OutlierOptions{
+		Interval:                 time.Second * 10,
+		ConsecutiveFailures:      5,
+		SuccessRateMinimumHosts:  5,
+		SuccessRateRequestVolume: 100,
+		SuccessRateStdevFactor:   1.9,
+		BaseEjectionTime:         time.Second * 30,
+		MaxEjectionTime:          time.Second * 300,
+		MaxEjectionPercent:       10,
+		Logger:                   logger.With(logger.FieldMod("transport.outlier")),
+	}
+}
+
+// outlierHost 节点检测状态
+type outlierHost struct {
+	node         Node
+	consecutive  int
+	success      int64
+	total        int64
+	ejections    int
+	ejected      bool
+	ejectedUntil time.Time
+}
+
+// isEjected 节点在指定时间是否处于驱逐中
+func (h *outlierHost) isEjected(now time.Time) bool {
+	return h.ejected && now.Before(h.ejectedUntil)
+}
+
+// outlierDetector 异常节点检测器
+type outlierDetector struct {
+	opts     *OutlierOptions
+	mu       sync.RWMutex
+	hosts    map[string]*outlierHost
+	lastEval time.Time
+	nextEval time.Time // 下次需要检测的时间，取统计周期结束与最早驱逐到期时间的较小值
+	now      func() time.Time
+}
+
+func newOutlierDetector(opts *OutlierOptions) *outlierDetector {
+	now := time.Now()
+	return &outlierDetector{
+		opts:     opts,
+		hosts:    make(map[string]*outlierHost),
+		lastEval: now,
+		nextEval: now.Add(opts.Interval),
+		now:      time.Now,
+	}
+}
+
+// retain 节点更新时保留仍存在节点的检测状态
+func (d *outlierDetector) retain(nodes []IWeightedNode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hosts := make(map[string]*outlierHost, len(nodes))
+	for _, n := range nodes {
+		key := nodeKey(n)
+		h, ok := d.hosts[key]
+		if !ok {
+			h = &outlierHost{}
+		}
+		h.node = n.Raw()
+		hosts[key] = h
+	}
+	d.hosts = hosts
+}
+
+// filter 过滤被驱逐的节点，全部被驱逐时返回原节点，只读取驱逐状态，驱逐到期的节点直接视为可用
+func (d *outlierDetector) filter(nodes []IWeightedNode) []IWeightedNode {
+	now := d.now()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ejected := 0
+	for _, n := range nodes {
+		if h, ok := d.hosts[nodeKey(n)]; ok && h.isEjected(now) {
+			ejected++
+		}
+	}
+	if ejected == 0 || ejected == len(nodes) {
+		return nodes
+	}
+	res := make([]IWeightedNode, 0, len(nodes)-ejected)
+	for _, n := range nodes {
+		if h, ok := d.hosts[nodeKey(n)]; !ok || !h.isEjected(now) {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// record 记录一次调用结果，到达检测时间时恢复到期节点并按成功率驱逐节点
+func (d *outlierDetector) record(node Node, di DoneInfo) {
+	now := d.now()
+	d.mu.Lock()
+	var events []OutlierEvent
+	if !now.Before(d.nextEval) {
+		events = d.evaluate(now)
+	}
+	if h, ok := d.hosts[nodeKey(node)]; ok {
+		h.total++
+		if IsFailure(di.Err, d.opts.ErrHandler) {
+			h.consecutive++
+			if d.opts.ConsecutiveFailures > 0 && h.consecutive >= d.opts.ConsecutiveFailures && !h.ejected {
+				if event, ok := d.eject(h, OutlierConsecutiveFailures, now); ok {
+					events = append(events, event)
+				}
+			}
+		} else {
+			h.success++
+			h.consecutive = 0
+		}
+	}
+	d.mu.Unlock()
+	d.emit(events)
+}
+
+// evaluate 恢复到期的节点，并在统计周期结束时按成功率驱逐节点，需持有锁
+func (d *outlierDetector) evaluate(now time.Time) (events []OutlierEvent) {
+	due := now.Sub(d.lastEval) >= d.opts.Interval
+	for _, h := range d.hosts {
+		if h.ejected {
+			if !h.isEjected(now) {
+				h.ejected = false
+				events = append(events, OutlierEvent{Type: OutlierUneject, Node: h.node})
+			}
+		} else if due && h.ejections > 0 {
+			// 一个周期内未被驱逐则降低驱逐倍数
+			h.ejections--
+		}
+	}
+	if due {
+		d.lastEval = now
+		if d.opts.SuccessRateStdevFactor > 0 {
+			events = append(events, d.ejectBySuccessRate(now)...)
+		}
+		for _, h := range d.hosts {
+			h.success, h.total = 0, 0
+		}
+	}
+	d.nextEval = d.lastEval.Add(d.opts.Interval)
+	for _, h := range d.hosts {
+		if h.ejected && h.ejectedUntil.Before(d.nextEval) {
+			d.nextEval = h.ejectedUntil
+		}
+	}
+	return
+}
+
+// ejectBySuccessRate 驱逐成功率低于 平均值-标准差*系数 的节点
+func (d *outlierDetector) ejectBySuccessRate(now time.Time) (events []OutlierEvent) {
+	var (
+		candidates []*outlierHost
+		rates      []float64
+		sum        float64
+	)
+	for _, h := range d.hosts {
+		if h.ejected || h.total < d.opts.SuccessRateRequestVolume || h.total == 0 {
+			continue
+		}
+		rate := float64(h.success) / float64(h.total)
+		candidates = append(candidates, h)
+		rates = append(rates, rate)
+		sum += rate
+	}
+	if len(candidates) == 0 || len(candidates) < d.opts.SuccessRateMinimumHosts {
+		return
+	}
+	mean := sum / float64(len(rates))
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	threshold := mean - math.Sqrt(variance/float64(len(rates)))*d.opts.SuccessRateStdevFactor
+	for i, h := range candidates {
+		if rates[i] >= threshold {
+			continue
+		}
+		if event, ok := d.eject(h, OutlierSuccessRate, now); ok {
+			event.SuccessRate = rates[i]
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+// eject 驱逐节点，驱逐时长按驱逐次数指数增长，超过最大驱逐比例时不驱逐，需持有锁
+func (d *outlierDetector) eject(h *outlierHost, typ OutlierEventType, now time.Time) (OutlierEvent, bool) {
+	ejected := 0
+	for _, host := range d.hosts {
+		if host.ejected {
+			ejected++
+		}
+	}
+	maxEjected := len(d.hosts) * d.opts.MaxEjectionPercent / 100
+	if maxEjected < 1 {
+		maxEjected = 1
+	}
+	if ejected >= maxEjected {
+		return OutlierEvent{}, false
+	}
+	h.ejections++
+	duration := d.opts.BaseEjectionTime
+	for i := 1; i < h.ejections && duration < d.opts.MaxEjectionTime; i++ {
+		duration *= 2
+	}
+	if d.opts.MaxEjectionTime > 0 && duration > d.opts.MaxEjectionTime {
+		duration = d.opts.MaxEjectionTime
+	}
+	h.ejected = true
+	h.ejectedUntil = now.Add(duration)
+	h.consecutive = 0
+	if h.ejectedUntil.Before(d.nextEval) {
+		d.nextEval = h.ejectedUntil
+	}
+	return OutlierEvent{Type: typ, Node: h.node, EjectionTime: duration}, true
+}
+
+// emit 输出事件日志并调用钩子
+func (d *outlierDetector) emit(events []OutlierEvent) {
+	for _, event := range events {
+		if d.opts.Logger != nil {
+			if event.Type == OutlierUneject {
+				d.opts.Logger.Infof("[outlier] node %s restored", event.Node.Address())
+			} else {
+				d.opts.Logger.Warnf("[outlier] node %s ejected for %s, reason: %s", event.Node.Address(), event.EjectionTime, event.Type)
+			}
+		}
+		if d.opts.OnEvent != nil {
+			d.opts.OnEvent(event)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"testing"
+	"time"
+)
+
+func newOutlierSelector(n int, opts *OutlierOptions) (*DefaultSelector, *time.Time) {
+	s := (&DefaultSelectorBuilder{
+		Name:             selectorName,
+		BalancerBuilder:  &DefaultBalancerBuilder{},
+		WightNodeBuilder: &DefaultWeightNodeBuilder{},
+		Outlier:          opts,
+	}).Build().(*DefaultSelector)
+	now := time.Now()
+	s.outlier.now = func() time.Time { return now }
+	s.outlier.lastEval = now
+	s.outlier.nextEval = now.Add(opts.Interval)
+	nodes := make([]Node, 0, n)
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, NewNode("grpc", fmt.Sprintf("127.0.0.%d:9000", i+1), &ServiceInfo{ID: fmt.Sprint(i)}))
+	}
+	s.Store(nodes)
+	return s, &now
+}
+
+func TestOutlierConsecutiveFailures(t *testing.T) {
+	var events []OutlierEvent
+	opts := DefaultOutlierOptions()
+	opts.Logger = nil
+	opts.MaxEjectionPercent = 50
+	opts.OnEvent = func(event OutlierEvent) {
+		events = append(events, event)
+	}
+	s, now := newOutlierSelector(2, opts)
+	bad := s.Nodes()[0]
+	for i := 0; i < opts.ConsecutiveFailures; i++ {
+		s.outlier.record(bad.Raw(), DoneInfo{Err: errors.ServiceUnavailable("UNAVAILABLE", "")})
+	}
+	if len(events) != 1 || events[0].Type != OutlierConsecutiveFailures || events[0].EjectionTime != opts.BaseEjectionTime {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	for i := 0; i < 20; i++ {
+		node, done, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address() == bad.Address() {
+			t.Fatal("ejected node was picked")
+		}
+		done(context.Background(), DoneInfo{})
+	}
+	// 驱逐到期后节点直接可用，下一次调用结束时输出恢复事件
+	*now = now.Add(opts.BaseEjectionTime)
+	if got := len(s.outlier.filter(s.Nodes())); got != 2 || len(events) != 1 {
+		t.Fatalf("want 2 available nodes without new events, got %d %+v", got, events)
+	}
+	s.outlier.record(s.Nodes()[1].Raw(), DoneInfo{})
+	if len(events) != 2 || events[1].Type != OutlierUneject {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	// 再次驱逐时长翻倍
+	for i := 0; i < opts.ConsecutiveFailures; i++ {
+		s.outlier.record(bad.Raw(), DoneInfo{Err: context.DeadlineExceeded})
+	}
+	if len(events) != 3 || events[2].EjectionTime != opts.BaseEjectionTime*2 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestOutlierMaxEjectionPercent(t *testing.T) {
+	opts := DefaultOutlierOptions()
+	opts.Logger = nil
+	s, _ := newOutlierSelector(4, opts)
+	nodes := s.Nodes()
+	for _, n := range nodes[:2] {
+		for i := 0; i < opts.ConsecutiveFailures; i++ {
+			s.outlier.record(n.Raw(), DoneInfo{Err: context.DeadlineExceeded})
+		}
+	}
+	// 10% 的4个节点向下取整为0，至少允许驱逐一个节点
+	if got := len(s.outlier.filter(nodes)); got != 3 {
+		t.Fatalf("want 3 available nodes, got %d", got)
+	}
+}
+
+func TestOutlierSuccessRate(t *testing.T) {
+	var events []OutlierEvent
+	opts := DefaultOutlierOptions()
+	opts.Logger = nil
+	opts.ConsecutiveFailures = 0
+	opts.OnEvent = func(event OutlierEvent) {
+		events = append(events, event)
+	}
+	s, now := newOutlierSelector(5, opts)
+	nodes := s.Nodes()
+	for i, n := range nodes {
+		for j := 0; j < 100; j++ {
+			var err error
+			// 第一个节点一半请求失败
+			if i == 0 && j%2 == 0 {
+				err = errors.ServiceUnavailable("UNAVAILABLE", "")
+			}
+			s.outlier.record(n.Raw(), DoneInfo{Err: err})
+		}
+	}
+	*now = now.Add(opts.Interval)
+	s.outlier.record(nodes[1].Raw(), DoneInfo{})
+	available := s.outlier.filter(nodes)
+	if len(events) != 1 || events[0].Type != OutlierSuccessRate || events[0].Node.Address() != nodes[0].Address() {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if len(available) != 4 {
+		t.Fatalf("want 4 available nodes, got %d", len(available))
+	}
+}
+
+func TestBuildSelectorWithOutlier(t *testing.T) {
+	s, err := BuildSelectorWithOutlier("", DefaultOutlierOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds, ok := s.(*DefaultSelector); !ok || ds.outlier == nil {
+		t.Fatalf("want selector with outlier detection, got %T", s)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// budget 令牌桶重试预算，失败消耗令牌，成功返还部分令牌
+type budget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+func newBudget(max, ratio float64) *budget {
+	return &budget{
+		tokens: max,
+		max:    max,
+		ratio:  ratio,
+	}
+}
+
+// allow 令牌数大于容量的一半时允许重试
+func (b *budget) allow() bool {
+	if b.max <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
+
+func (b *budget) success() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.max, b.tokens+b.ratio)
+	b.mu.Unlock()
+}
+
+func (b *budget) failure() {
+	b.mu.Lock()
+	b.tokens = math.Max(0, b.tokens-1)
+	b.mu.Unlock()
+}
+
+const (
+	latencySize  = 256 // 保留的最近耗时样本数
+	latencyFlush = 32  // 每新增多少个样本重新计算一次分位值
+)
+
+// latency 最近请求耗时分位统计
+type latency struct {
+	mu         sync.Mutex
+	samples    []time.Duration
+	next       int
+	added      int
+	percentile float64
+	cached     time.Duration
+}
+
+// newLatency 创建耗时统计，分位值大于1时按1处理
+func newLatency(percentile float64) *latency {
+	if percentile > 1 {
+		percentile = 1
+	}
+	return &latency{
+		samples:    make([]time.Duration, 0, latencySize),
+		percentile: percentile,
+	}
+}
+
+func (l *latency) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < latencySize {
+		l.samples = append(l.samples, d)
+	} else {
+		l.samples[l.next] = d
+		l.next = (l.next + 1) % latencySize
+	}
+	l.added++
+	if l.added%latencyFlush == 1 {
+		sorted := make([]time.Duration, len(l.samples))
+		copy(sorted, l.samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		l.cached = sorted[int(float64(len(sorted)-1)*l.percentile)]
+	}
+}
+
+// value 当前分位耗时，没有样本时返回0
+func (l *latency) value() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cached
+}
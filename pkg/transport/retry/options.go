@@ -0,0 +1,156 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"time"
+)
+
+// Option 重试策略参数
+type Option func(o *Options)
+
+// Options 重试策略参数信息
+type Options struct {
+	MaxAttempts       int                  `json:"maxAttempts"`       // 最大尝试次数(包含首次请求)，默认值：1，即不重试
+	InitialBackoff    time.Duration        `json:"initialBackoff"`    // 首次重试的退避时间，默认值：50ms
+	MaxBackoff        time.Duration        `json:"maxBackoff"`        // 最大退避时间，默认值：1s
+	Multiplier        float64              `json:"multiplier"`        // 退避时间增长倍数，默认值：2
+	Jitter            float64              `json:"jitter"`            // 退避时间随机抖动比例，默认值：0.2
+	Codes             []int32              `json:"codes"`             // 可重试的错误码，默认值：[503]，http客户端的连接级错误同样转换为503
+	Reasons           []string             `json:"reasons"`           // 可重试的错误原因
+	BudgetMaxTokens   float64              `json:"budgetMaxTokens"`   // 重试预算令牌桶容量，令牌数不超过一半时停止重试，默认值：10
+	BudgetTokenRatio  float64              `json:"budgetTokenRatio"`  // 每次成功请求返还的令牌数，默认值：0.1
+	HedgingPercentile float64              `json:"hedgingPercentile"` // 对冲请求触发的延迟分位，取值0~1，为0时不开启对冲，大于1时按1处理，例如：0.95
+	HedgingDelay      time.Duration        `json:"hedgingDelay"`      // 对冲请求的最小延迟，没有延迟统计时使用该值，默认值：100ms
+	retryable         func(err error) bool // 自定义可重试错误判定
+}
+
+// DefaultOptions 默认参数
+func DefaultOptions() *Options {
+	return &Options{
+		MaxAttempts:      1,
+		InitialBackoff:   50 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		Multiplier:       2,
+		Jitter:           0.2,
+		Codes:            []int32{503},
+		BudgetMaxTokens:  10,
+		BudgetTokenRatio: 0.1,
+		HedgingDelay:     100 * time.Millisecond,
+	}
+}
+
+// WithOption 设置参数
+func (o *Options) WithOption(opts ...Option) *Options {
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Build 构建重试器
+func (o *Options) Build() *Retrier {
+	return NewWithOptions(o)
+}
+
+// Retryable 判断错误是否可以重试
+func (o *Options) Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if o.retryable != nil {
+		return o.retryable(err)
+	}
+	e := errors.FromError(err)
+	for _, code := range o.Codes {
+		if e.Code == code {
+			return true
+		}
+	}
+	for _, reason := range o.Reasons {
+		if e.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMaxAttempts 设置最大尝试次数
+func WithMaxAttempts(attempts int) Option {
+	return func(o *Options) {
+		o.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff 设置退避时间
+func WithBackoff(initial, max time.Duration) Option {
+	return func(o *Options) {
+		o.InitialBackoff = initial
+		o.MaxBackoff = max
+	}
+}
+
+// WithMultiplier 设置退避时间增长倍数
+func WithMultiplier(multiplier float64) Option {
+	return func(o *Options) {
+		o.Multiplier = multiplier
+	}
+}
+
+// WithJitter 设置退避时间随机抖动比例
+func WithJitter(jitter float64) Option {
+	return func(o *Options) {
+		o.Jitter = jitter
+	}
+}
+
+// WithCodes 设置可重试的错误码
+func WithCodes(codes ...int32) Option {
+	return func(o *Options) {
+		o.Codes = codes
+	}
+}
+
+// WithReasons 设置可重试的错误原因
+func WithReasons(reasons ...string) Option {
+	return func(o *Options) {
+		o.Reasons = reasons
+	}
+}
+
+// WithRetryable 自定义可重试错误判定，设置后忽略错误码与错误原因配置
+func WithRetryable(fn func(err error) bool) Option {
+	return func(o *Options) {
+		o.retryable = fn
+	}
+}
+
+// WithBudget 设置重试预算
+func WithBudget(maxTokens, tokenRatio float64) Option {
+	return func(o *Options) {
+		o.BudgetMaxTokens = maxTokens
+		o.BudgetTokenRatio = tokenRatio
+	}
+}
+
+// WithHedging 开启对冲请求，请求耗时超过延迟分位后并行发起下一次尝试，percentile 取值0~1，如0.95
+func WithHedging(percentile float64, minDelay time.Duration) Option {
+	return func(o *Options) {
+		o.HedgingPercentile = percentile
+		o.HedgingDelay = minDelay
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/transport"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Call 单次尝试，attempt 从0开始，每次尝试都会重新经过节点选择器
+type Call func(ctx context.Context, attempt int) (interface{}, error)
+
+// Retrier 重试器
+type Retrier struct {
+	opts    *Options
+	budget  *budget
+	latency *latency
+
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// New 创建重试器
+func New(opts ...Option) *Retrier {
+	return NewWithOptions(DefaultOptions().WithOption(opts...))
+}
+
+// NewWithOptions 根据参数创建重试器
+func NewWithOptions(o *Options) *Retrier {
+	r := &Retrier{
+		opts:   o,
+		budget: newBudget(o.BudgetMaxTokens, o.BudgetTokenRatio),
+		r:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if o.HedgingPercentile > 0 {
+		r.latency = newLatency(o.HedgingPercentile)
+	}
+	return r
+}
+
+// Hedging 是否开启了对冲请求，开启后多次尝试可能并发执行
+func (r *Retrier) Hedging() bool {
+	return r.latency != nil
+}
+
+// Do 按重试策略执行调用
+func (r *Retrier) Do(ctx context.Context, call Call) (interface{}, error) {
+	if r.Hedging() {
+		return r.hedge(ctx, call)
+	}
+	var (
+		reply interface{}
+		err   error
+	)
+	for attempt := 0; attempt < r.maxAttempts(); attempt++ {
+		if attempt > 0 {
+			if !r.budget.allow() {
+				break
+			}
+			timer := time.NewTimer(r.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return reply, err
+			case <-timer.C:
+			}
+		}
+		reply, err = r.call(ctx, call, attempt)
+		if err == nil {
+			r.budget.success()
+			return reply, nil
+		}
+		if !r.opts.Retryable(err) || ctx.Err() != nil {
+			return reply, err
+		}
+		r.budget.failure()
+	}
+	return reply, err
+}
+
+type result struct {
+	reply interface{}
+	err   error
+	peer  *transport.Peer
+}
+
+// hedge 对冲请求，上一次尝试超过延迟分位仍未返回时并行发起下一次尝试，取最先成功的结果
+func (r *Retrier) hedge(ctx context.Context, call Call) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, r.maxAttempts())
+	launched, pending := 0, 0
+	launch := func() {
+		attempt := launched
+		launched++
+		pending++
+		go func() {
+			p := &transport.Peer{}
+			reply, err := r.call(transport.NewPeerContext(ctx, p), call, attempt)
+			results <- result{reply: reply, err: err, peer: p}
+		}()
+	}
+	launch()
+	timer := time.NewTimer(r.hedgingDelay())
+	defer timer.Stop()
+	var last result
+	for {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil || !r.opts.Retryable(res.err) {
+				if res.err == nil {
+					r.budget.success()
+				}
+				if p, ok := transport.FromPeerContext(ctx); ok {
+					p.Node = res.peer.Node
+				}
+				return res.reply, res.err
+			}
+			r.budget.failure()
+			last = res
+			if launched < r.maxAttempts() && r.budget.allow() {
+				launch()
+				resetTimer(timer, r.hedgingDelay())
+			} else if pending == 0 {
+				return last.reply, last.err
+			}
+		case <-timer.C:
+			if launched < r.maxAttempts() && r.budget.allow() {
+				launch()
+				timer.Reset(r.hedgingDelay())
+			}
+		case <-ctx.Done():
+			if last.err != nil {
+				return last.reply, last.err
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// resetTimer 重置定时器，丢弃已触发但未读取的信号
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+// call 执行一次尝试并记录耗时
+func (r *Retrier) call(ctx context.Context, call Call, attempt int) (interface{}, error) {
+	start := time.Now()
+	reply, err := call(ctx, attempt)
+	if err == nil && r.latency != nil {
+		r.latency.add(time.Since(start))
+	}
+	return reply, err
+}
+
+func (r *Retrier) maxAttempts() int {
+	if r.opts.MaxAttempts < 1 {
+		return 1
+	}
+	return r.opts.MaxAttempts
+}
+
+// backoff 指数退避时间，附加随机抖动
+func (r *Retrier) backoff(attempt int) time.Duration {
+	d := float64(r.opts.InitialBackoff) * math.Pow(r.opts.Multiplier, float64(attempt-1))
+	if max := float64(r.opts.MaxBackoff); max > 0 && d > max {
+		d = max
+	}
+	if r.opts.Jitter > 0 {
+		r.mu.Lock()
+		d = d * (1 + r.opts.Jitter*(r.r.Float64()*2-1))
+		r.mu.Unlock()
+	}
+	return time.Duration(d)
+}
+
+// hedgingDelay 对冲请求延迟
+func (r *Retrier) hedgingDelay() time.Duration {
+	if d := r.latency.value(); d > r.opts.HedgingDelay {
+		return d
+	}
+	return r.opts.HedgingDelay
+}
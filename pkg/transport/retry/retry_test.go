@@ -0,0 +1,105 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"github.com/go-ceres/ceres/pkg/common/errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetrier(t *testing.T) {
+	r := New(WithMaxAttempts(3), WithBackoff(time.Millisecond, 5*time.Millisecond))
+	var calls int32
+	_, err := r.Do(context.Background(), func(ctx context.Context, attempt int) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	})
+	if !errors.IsServiceUnavailable(err) || calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d: %v", calls, err)
+	}
+
+	calls = 0
+	_, err = r.Do(context.Background(), func(ctx context.Context, attempt int) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.BadRequest("BAD_REQUEST", "bad request")
+	})
+	if !errors.IsBadRequest(err) || calls != 1 {
+		t.Fatalf("non retryable error should not be retried, got %d attempts", calls)
+	}
+
+	calls = 0
+	reply, err := r.Do(context.Background(), func(ctx context.Context, attempt int) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if attempt == 0 {
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		}
+		return "ok", nil
+	})
+	if err != nil || reply != "ok" || calls != 2 {
+		t.Fatalf("expected success on second attempt, got %d attempts: %v", calls, err)
+	}
+}
+
+func TestRetrierBudget(t *testing.T) {
+	r := New(WithMaxAttempts(3), WithBackoff(time.Millisecond, time.Millisecond), WithBudget(4, 0.1))
+	var calls int32
+	for i := 0; i < 10; i++ {
+		_, _ = r.Do(context.Background(), func(ctx context.Context, attempt int) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		})
+	}
+	// 预算耗尽后不再重试，只剩首次请求
+	if calls >= 30 || calls < 10 {
+		t.Fatalf("retry budget should limit retries, got %d attempts", calls)
+	}
+}
+
+func TestRetrierHedging(t *testing.T) {
+	r := New(WithMaxAttempts(2), WithHedging(0.9, 10*time.Millisecond))
+	start := time.Now()
+	reply, err := r.Do(context.Background(), func(ctx context.Context, attempt int) (interface{}, error) {
+		if attempt == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return "slow", nil
+			}
+		}
+		return "fast", nil
+	})
+	if err != nil || reply != "fast" {
+		t.Fatalf("expected hedged attempt to win, got %v: %v", reply, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("hedged request should not wait for the slow attempt")
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	// 超出范围的分位值不能导致越界
+	l := newLatency(95)
+	for i := 1; i <= 100; i++ {
+		l.add(time.Duration(i) * time.Millisecond)
+	}
+	if d := l.value(); d != 97*time.Millisecond {
+		t.Fatalf("want max sample of last flush, got %s", d)
+	}
+}
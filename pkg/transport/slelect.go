@@ -17,7 +17,9 @@ package transport
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-ceres/ceres/pkg/common/errors"
+	"sync"
 	"sync/atomic"
 )
 
@@ -51,6 +53,26 @@ func WithNodeFilter(filters ...NodeFilter) SelectOption {
 	}
 }
 
+type nodeFilterKey struct{}
+
+// NewNodeFilterContext 在上下文中追加节点过滤器，选择节点时与参数中的过滤器一起生效
+func NewNodeFilterContext(ctx context.Context, filters ...NodeFilter) context.Context {
+	if len(filters) == 0 {
+		return ctx
+	}
+	parent := NodeFiltersFromContext(ctx)
+	merged := make([]NodeFilter, 0, len(parent)+len(filters))
+	merged = append(merged, parent...)
+	merged = append(merged, filters...)
+	return context.WithValue(ctx, nodeFilterKey{}, merged)
+}
+
+// NodeFiltersFromContext 获取上下文中的节点过滤器
+func NodeFiltersFromContext(ctx context.Context) []NodeFilter {
+	filters, _ := ctx.Value(nodeFilterKey{}).([]NodeFilter)
+	return filters
+}
+
 // SelectOptions 选择节点参数信息
 type SelectOptions struct {
 	filters []NodeFilter
@@ -61,20 +83,54 @@ type SelectorBuilder interface {
 	Build() Selector
 }
 
+// BalancerSelectorBuilder 支持替换负载均衡器的选择器构建器
+type BalancerSelectorBuilder interface {
+	SelectorBuilder
+	// WithBalancer 返回使用指定负载均衡器的选择器构建器
+	WithBalancer(name string, builder BalancerBuilder) SelectorBuilder
+}
+
+// OutlierSelectorBuilder 支持异常节点检测的选择器构建器
+type OutlierSelectorBuilder interface {
+	SelectorBuilder
+	// WithOutlier 返回开启异常节点检测的选择器构建器
+	WithOutlier(opts *OutlierOptions) SelectorBuilder
+}
+
 // DefaultSelectorBuilder 默认的选择器的构建器
 type DefaultSelectorBuilder struct {
 	Name             string
 	WightNodeBuilder WeightNodeBuilder
 	BalancerBuilder  BalancerBuilder
+	Outlier          *OutlierOptions // 异常节点检测配置，为空时不检测
 }
 
 // Build 构建选择器方法
 func (s *DefaultSelectorBuilder) Build() Selector {
-	return &DefaultSelector{
+	selector := &DefaultSelector{
 		name:              s.Name,
 		weightNodeBuilder: s.WightNodeBuilder,
 		balancer:          s.BalancerBuilder.Build(),
 	}
+	if s.Outlier != nil {
+		selector.outlier = newOutlierDetector(s.Outlier)
+	}
+	return selector
+}
+
+// WithBalancer 返回使用指定负载均衡器的选择器构建器
+func (s *DefaultSelectorBuilder) WithBalancer(name string, builder BalancerBuilder) SelectorBuilder {
+	clone := *s
+	clone.Name = name
+	clone.BalancerBuilder = builder
+	return &clone
+}
+
+// WithOutlier 返回开启异常节点检测的选择器构建器
+func (s *DefaultSelectorBuilder) WithOutlier(opts *OutlierOptions) SelectorBuilder {
+	clone := *s
+	clone.Outlier = opts
+	return &clone
 }
 
 // Selector 选择器接口
@@ -93,6 +149,9 @@ type DefaultSelector struct {
 	balancer          Balancer          // 负载均衡构建器
 	name              string            // 选择器名称
 	nodes             atomic.Value
+	mu                sync.Mutex
+	weighted          map[string]IWeightedNode // 节点唯一标识对应的权重节点
+	outlier           *outlierDetector         // 异常节点检测器
 }
 
 // Name 选择器名称
@@ -100,15 +159,62 @@ func (s *DefaultSelector) Name() string {
 	return s.name
 }
 
-// Store 存储节点
+// Store 存储节点，与上一次的节点按地址与服务ID比对，未变化的节点保留统计数据，只创建新增的节点
 func (s *DefaultSelector) Store(nodes []Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	weighted := make(map[string]IWeightedNode, len(nodes))
 	weightedNodes := make([]IWeightedNode, 0, len(nodes))
 	for _, n := range nodes {
-		weightedNodes = append(weightedNodes, s.weightNodeBuilder.Build(n))
+		key := nodeKey(n)
+		if _, dup := weighted[key]; dup {
+			// 重复的节点单独创建
+			weightedNodes = append(weightedNodes, s.weightNodeBuilder.Build(n))
+			continue
+		}
+		wn, ok := s.weighted[key]
+		if !ok || !reuseNode(wn, n) {
+			wn = s.weightNodeBuilder.Build(n)
+		}
+		weighted[key] = wn
+		weightedNodes = append(weightedNodes, wn)
+	}
+	s.weighted = weighted
+	if s.outlier != nil {
+		s.outlier.retain(weightedNodes)
+	}
+	if updater, ok := s.balancer.(BalancerUpdater); ok {
+		updater.Update(weightedNodes)
 	}
 	s.nodes.Store(weightedNodes)
 }
 
+// nodeKey 节点唯一标识，由地址与服务ID组成
+func nodeKey(n Node) string {
+	if info := n.ServiceInfo(); info != nil {
+		return n.Address() + "#" + info.ID
+	}
+	return n.Address()
+}
+
+// reuseNode 复用已有的权重节点，原始节点变化时替换为新的原始节点
+func reuseNode(wn IWeightedNode, n Node) bool {
+	if wn.Raw() == n {
+		return true
+	}
+	if updater, ok := wn.(WeightedNodeUpdater); ok {
+		updater.Update(n)
+		return true
+	}
+	return false
+}
+
+// Nodes 当前存储的权重节点
+func (s *DefaultSelector) Nodes() []IWeightedNode {
+	nodes, _ := s.nodes.Load().([]IWeightedNode)
+	return nodes
+}
+
 // Select 选择节点
 func (s *DefaultSelector) Select(ctx context.Context, opts ...SelectOption) (selected Node, doneFunc DoneFunc, err error) {
 	var (
@@ -122,6 +228,9 @@ func (s *DefaultSelector) Select(ctx context.Context, opts ...SelectOption) (sel
 	for _, o := range opts {
 		o(&options)
 	}
+	if filters := NodeFiltersFromContext(ctx); len(filters) > 0 {
+		options.filters = append(options.filters[:len(options.filters):len(options.filters)], filters...)
+	}
 	if len(options.filters) > 0 {
 		newNodes := make([]Node, len(nodes))
 		for i, wc := range nodes {
@@ -141,10 +250,20 @@ func (s *DefaultSelector) Select(ctx context.Context, opts ...SelectOption) (sel
 	if len(candidates) == 0 {
 		return nil, nil, ErrNoAvailable
 	}
+	if s.outlier != nil {
+		candidates = s.outlier.filter(candidates)
+	}
 	wn, done, err := s.balancer.Pick(ctx, candidates)
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.outlier != nil {
+		raw, pickDone := wn.Raw(), done
+		done = func(ctx context.Context, di DoneInfo) {
+			pickDone(ctx, di)
+			s.outlier.record(raw, di)
+		}
+	}
 	p, ok := FromPeerContext(ctx)
 	if ok {
 		p.Node = wn.Raw()
@@ -161,3 +280,32 @@ func SetSelectorBuilder(builder SelectorBuilder) {
 func GetSelectorBuilder() SelectorBuilder {
 	return selectorBuilder
 }
+
+// BuildSelector 使用全局选择器构建器创建选择器，balancer 不为空时使用该名称注册的负载均衡器
+func BuildSelector(balancer string) (Selector, error) {
+	return BuildSelectorWithOutlier(balancer, nil)
+}
+
+// BuildSelectorWithOutlier 同 BuildSelector，outlier 不为空时开启异常节点检测
+func BuildSelectorWithOutlier(balancer string, outlier *OutlierOptions) (Selector, error) {
+	builder := selectorBuilder
+	if balancer != "" {
+		bb, ok := GetBalancerBuilder(balancer)
+		if !ok {
+			return nil, fmt.Errorf("transport: unknown balancer %q", balancer)
+		}
+		sb, ok := builder.(BalancerSelectorBuilder)
+		if !ok {
+			return nil, fmt.Errorf("transport: selector builder does not support balancer %q", balancer)
+		}
+		builder = sb.WithBalancer(balancer, bb)
+	}
+	if outlier != nil {
+		ob, ok := builder.(OutlierSelectorBuilder)
+		if !ok {
+			return nil, fmt.Errorf("transport: selector builder does not support outlier detection")
+		}
+		builder = ob.WithOutlier(outlier)
+	}
+	return builder.Build(), nil
+}
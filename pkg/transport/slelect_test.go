@@ -0,0 +1,127 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestSelector() *DefaultSelector {
+	return (&DefaultSelectorBuilder{
+		Name:             selectorName,
+		BalancerBuilder:  &DefaultBalancerBuilder{},
+		WightNodeBuilder: &DefaultWeightNodeBuilder{},
+	}).Build().(*DefaultSelector)
+}
+
+func nodeByAddress(s *DefaultSelector, address string) IWeightedNode {
+	for _, n := range s.Nodes() {
+		if n.Address() == address {
+			return n
+		}
+	}
+	return nil
+}
+
+func TestSelectorStoreChurn(t *testing.T) {
+	s := newTestSelector()
+	s.Store([]Node{
+		NewNode("grpc", "127.0.0.1:9000", &ServiceInfo{ID: "a"}),
+		NewNode("grpc", "127.0.0.2:9000", &ServiceInfo{ID: "b"}),
+	})
+	a := nodeByAddress(s, "127.0.0.1:9000")
+	done := a.Pick()
+	time.Sleep(time.Millisecond * 5)
+	done(context.Background(), DoneInfo{})
+	inflight := a.Pick()
+	lag := a.(NodeStats).Lag()
+	if lag == 0 {
+		t.Fatal("lag not collected")
+	}
+
+	// 注册中心推送变更: a 节点信息更新，b 节点下线，c 节点上线
+	updated := NewNode("grpc", "127.0.0.1:9000", &ServiceInfo{ID: "a", Version: "v2"})
+	s.Store([]Node{
+		updated,
+		NewNode("grpc", "127.0.0.3:9000", &ServiceInfo{ID: "c"}),
+	})
+	if got := nodeByAddress(s, "127.0.0.1:9000"); got != a {
+		t.Fatal("weighted node of unchanged address was rebuilt")
+	}
+	if a.Raw() != updated {
+		t.Fatal("raw node was not updated")
+	}
+	if a.(NodeStats).Lag() != lag || a.(NodeStats).Inflight() != 1 {
+		t.Fatal("node statistics were lost")
+	}
+	if nodeByAddress(s, "127.0.0.2:9000") != nil {
+		t.Fatal("removed node still present")
+	}
+	if len(s.Nodes()) != 2 {
+		t.Fatalf("want 2 nodes, got %d", len(s.Nodes()))
+	}
+	// 更新前发出的请求完成后仍然计入同一节点
+	inflight(context.Background(), DoneInfo{})
+	if a.(NodeStats).Inflight() != 0 {
+		t.Fatal("inflight request not released")
+	}
+
+	// 服务ID变化视为新的实例
+	s.Store([]Node{NewNode("grpc", "127.0.0.1:9000", &ServiceInfo{ID: "a2"})})
+	if nodeByAddress(s, "127.0.0.1:9000") == a {
+		t.Fatal("node with new service id should be rebuilt")
+	}
+}
+
+func TestSelectorStoreConcurrentSelect(t *testing.T) {
+	s := newTestSelector()
+	nodes := []Node{
+		NewNode("grpc", "127.0.0.1:9000", &ServiceInfo{ID: "a"}),
+		NewNode("grpc", "127.0.0.2:9000", &ServiceInfo{ID: "b"}),
+	}
+	s.Store(nodes)
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			_, done, err := s.Select(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			done(context.Background(), DoneInfo{})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		s.Store([]Node{
+			NewNode("grpc", "127.0.0.1:9000", &ServiceInfo{ID: "a"}),
+			NewNode("grpc", "127.0.0.2:9000", &ServiceInfo{ID: "b"}),
+		})
+	}
+	close(stop)
+	<-finished
+	if len(s.Nodes()) != 2 {
+		t.Fatalf("want 2 nodes, got %d", len(s.Nodes()))
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"github.com/go-ceres/ceres"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// SubsetOptions 子集划分配置，使用 rendezvous hash 为每个客户端挑选稳定且分布均匀的实例子集，
+// 后端实例很多时可以显著降低每个客户端的连接数
+type SubsetOptions struct {
+	Size int    `json:"size"` // 子集大小，小于等于0时不开启
+	Key  string `json:"key"`  // 客户端标识，相同标识得到相同子集，默认使用应用ID
+}
+
+// DefaultSubsetOptions 默认子集划分配置
+func DefaultSubsetOptions() *SubsetOptions {
+	return &SubsetOptions{}
+}
+
+// Subset 从服务实例中挑选子集，实例数量不超过子集大小时原样返回，返回结果保持实例原有顺序
+func (o *SubsetOptions) Subset(instances []*ServiceInfo) []*ServiceInfo {
+	if o == nil || o.Size <= 0 || len(instances) <= o.Size {
+		return instances
+	}
+	key := o.Key
+	if key == "" {
+		key = ceres.AppId()
+	}
+	type scored struct {
+		index int
+		score uint64
+	}
+	scores := make([]scored, len(instances))
+	for i, ins := range instances {
+		scores[i] = scored{index: i, score: rendezvousHash(key, instanceKey(ins))}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].index < scores[j].index
+	})
+	picked := make([]bool, len(instances))
+	for _, s := range scores[:o.Size] {
+		picked[s.index] = true
+	}
+	res := make([]*ServiceInfo, 0, o.Size)
+	for i, ins := range instances {
+		if picked[i] {
+			res = append(res, ins)
+		}
+	}
+	return res
+}
+
+// instanceKey 实例唯一标识，优先使用实例ID
+func instanceKey(ins *ServiceInfo) string {
+	if ins.ID != "" {
+		return ins.ID
+	}
+	return strings.Join(ins.Endpoints, ",")
+}
+
+// rendezvousHash 计算客户端与实例组合的权重
+func rendezvousHash(client, instance string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(client))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(instance))
+	x := h.Sum64()
+	// fnv 低位雪崩效果较差，再做一次混淆
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
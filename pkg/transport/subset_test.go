@@ -0,0 +1,95 @@
+// Copyright 2024. ceres
+// Author https://github.com/go-ceres/ceres
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"fmt"
+	"testing"
+)
+
+func subsetInstances(n int) []*ServiceInfo {
+	res := make([]*ServiceInfo, 0, n)
+	for i := 0; i < n; i++ {
+		res = append(res, &ServiceInfo{ID: fmt.Sprintf("ins-%d", i), Endpoints: []string{fmt.Sprintf("grpc://10.0.0.%d:9000", i)}})
+	}
+	return res
+}
+
+func TestSubsetStable(t *testing.T) {
+	instances := subsetInstances(50)
+	opts := &SubsetOptions{Size: 10, Key: "client-1"}
+	first := opts.Subset(instances)
+	if len(first) != 10 {
+		t.Fatalf("want 10 instances, got %d", len(first))
+	}
+	picked := make(map[string]bool)
+	for _, ins := range first {
+		picked[ins.ID] = true
+	}
+	// 顺序变化与移除未选中的实例都不应影响子集
+	reversed := make([]*ServiceInfo, 0, len(instances))
+	for i := len(instances) - 1; i >= 0; i-- {
+		if picked[instances[i].ID] || i%2 == 0 {
+			reversed = append(reversed, instances[i])
+		}
+	}
+	for _, ins := range opts.Subset(reversed) {
+		if !picked[ins.ID] {
+			t.Fatalf("subset changed, unexpected %s", ins.ID)
+		}
+	}
+	// 移除一个选中实例只替换该实例
+	removed := first[0].ID
+	rest := make([]*ServiceInfo, 0, len(instances))
+	for _, ins := range instances {
+		if ins.ID != removed {
+			rest = append(rest, ins)
+		}
+	}
+	changed := 0
+	for _, ins := range opts.Subset(rest) {
+		if !picked[ins.ID] {
+			changed++
+		}
+	}
+	if changed != 1 {
+		t.Fatalf("want 1 replaced instance, got %d", changed)
+	}
+	if got := (&SubsetOptions{Size: 60}).Subset(instances); len(got) != 50 {
+		t.Fatalf("want all instances, got %d", len(got))
+	}
+	var nilOpts *SubsetOptions
+	if got := nilOpts.Subset(instances); len(got) != 50 {
+		t.Fatalf("want all instances, got %d", len(got))
+	}
+}
+
+func TestSubsetDistribution(t *testing.T) {
+	instances := subsetInstances(50)
+	counts := make(map[string]int)
+	for i := 0; i < 500; i++ {
+		opts := &SubsetOptions{Size: 10, Key: fmt.Sprintf("client-%d", i)}
+		for _, ins := range opts.Subset(instances) {
+			counts[ins.ID]++
+		}
+	}
+	// 期望每个实例约被100个客户端选中
+	for _, ins := range instances {
+		if c := counts[ins.ID]; c < 60 || c > 140 {
+			t.Fatalf("uneven subset distribution, %s picked by %d clients", ins.ID, c)
+		}
+	}
+}
@@ -44,3 +44,8 @@ type Transport interface {
 type Endpointer interface {
 	Endpoint() (*url.URL, error)
 }
+
+// Drainer 可统计处理中请求数的服务，应用停止时用于等待请求处理完成
+type Drainer interface {
+	Inflight() int64
+}
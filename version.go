@@ -108,8 +108,8 @@ func StartTime() string {
 }
 
 // SetAppRegion 设置部属区域
-func SetAppRegion(region string) {
-	region = region
+func SetAppRegion(r string) {
+	region = r
 }
 
 // AppRegion 部属地域